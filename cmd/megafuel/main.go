@@ -0,0 +1,273 @@
+// Command megafuel checks sponsorability, sends gasless transactions, looks up their status and manages
+// the whitelists and spend data of MegaFuel policies, including syncing whitelists with a file.
+//
+// Usage:
+//
+//	megafuel <command> [flags] [args]
+//
+// The API key of the sponsor API and private policies is read from the MEGAFUEL_API_KEY environment
+// variable, or from the file named by MEGAFUEL_API_KEY_FILE, private keys from MEGAFUEL_PRIVATE_KEY. Run "megafuel help" for the list of commands.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/apikey"
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+const (
+	envAPIKey           = "MEGAFUEL_API_KEY"
+	envAPIKeyFile       = "MEGAFUEL_API_KEY_FILE"
+	envNetwork          = "MEGAFUEL_NETWORK"
+	envRPCURL           = "MEGAFUEL_RPC_URL"
+	envPrivateKey       = "MEGAFUEL_PRIVATE_KEY"
+	envKeystorePassword = "MEGAFUEL_KEYSTORE_PASSWORD"
+
+	defaultNetwork = "bsc-testnet"
+)
+
+// errUsage is returned by commands called with invalid arguments, after printing the usage.
+var errUsage = errors.New("usage error")
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, env *environment, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"sponsorable", "check whether a transaction is sponsorable", runSponsorable},
+		{"send", "sign and send a gasless transaction", runSend},
+		{"status", "show the status of a gasless transaction", runStatus},
+		{"bundle", "show a bundle, its sponsor transaction and the given user transactions", runBundle},
+		{"whitelist", "add, rm, list or empty whitelist values of a policy", runWhitelist},
+		{"spend", "show the spend data of a user or a policy", runSpend},
+		{"sync", "reconcile policy whitelists with a YAML or JSON file", runSync},
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// environment holds what commands need from the process.
+type environment struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+}
+
+// run runs the command line args and returns the exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	env := &environment{stdin: stdin, stdout: stdout, stderr: stderr, getenv: os.Getenv}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			err := cmd.run(ctx, env, args[1:])
+			switch {
+			case err == nil:
+				return 0
+			case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+				return 2
+			default:
+				fmt.Fprintf(stderr, "megafuel %s: %v\n", cmd.name, err)
+				return 1
+			}
+		}
+	}
+	fmt.Fprintf(stderr, "megafuel: unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: megafuel <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Environment:\n  %-21s API key of the sponsor API and private policies\n", envAPIKey)
+	fmt.Fprintf(w, "  %-21s file holding the API key, read again when it changes\n", envAPIKeyFile)
+	fmt.Fprintf(w, "  %-21s default network, one of %s\n", envNetwork, networkNames())
+	fmt.Fprintf(w, "  %-21s chain node endpoint of the bundle command\n", envRPCURL)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "megafuel <command> -h" for the flags of a command.`)
+}
+
+func networkNames() string {
+	var names []string
+	for _, n := range networks.All() {
+		names = append(names, n.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// options holds the flags shared by all commands.
+type options struct {
+	network      string
+	paymasterURL string
+	sponsorURL   string
+	policy       string
+	output       string
+	timeout      time.Duration
+}
+
+// newFlagSet returns a flag set for the command name with the shared flags registered into o.
+func newFlagSet(env *environment, name, usage string, o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "Usage: megafuel %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+
+	network := env.getenv(envNetwork)
+	if network == "" {
+		network = defaultNetwork
+	}
+	fs.StringVar(&o.network, "network", network, "network preset, one of "+networkNames())
+	fs.StringVar(&o.paymasterURL, "paymaster-url", "", "paymaster endpoint, overrides the network preset")
+	fs.StringVar(&o.sponsorURL, "sponsor-url", "", "sponsor endpoint, overrides the network preset")
+	fs.StringVar(&o.policy, "policy", "", "policy UUID")
+	fs.StringVar(&o.output, "o", "table", "output format, table or json")
+	fs.DurationVar(&o.timeout, "timeout", 30*time.Second, "timeout of the command")
+	return fs
+}
+
+// parse parses args and checks the shared flags and the number of positional arguments.
+func parse(fs *flag.FlagSet, o *options, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if o.output != "table" && o.output != "json" {
+		fmt.Fprintf(fs.Output(), "invalid output format %q\n", o.output)
+		fs.Usage()
+		return errUsage
+	}
+	if fs.NArg() < minArgs || maxArgs >= 0 && fs.NArg() > maxArgs {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func (o *options) resolveNetwork() (networks.Network, error) {
+	network, ok := networks.ByName(o.network)
+	if !ok {
+		return networks.Network{}, fmt.Errorf("unknown network %q, expected one of %s", o.network, networkNames())
+	}
+	return network, nil
+}
+
+// paymaster creates the paymaster client, for the private policy if one is given.
+func (o *options) paymaster(ctx context.Context, env *environment) (paymasterclient.Client, error) {
+	if o.paymasterURL != "" {
+		if o.policy == "" {
+			return paymasterclient.New(ctx, o.paymasterURL)
+		}
+		if !apikey.HasPlaceholder(o.paymasterURL) {
+			return paymasterclient.NewPrivatePaymaster(ctx, o.paymasterURL, o.policy)
+		}
+		cred, err := credential(env, "private policies")
+		if err != nil {
+			return nil, err
+		}
+		return paymasterclient.NewPrivatePaymasterWithCredential(ctx, o.paymasterURL, cred, o.policy)
+	}
+	network, err := o.resolveNetwork()
+	if err != nil {
+		return nil, err
+	}
+	if o.policy == "" {
+		return paymasterclient.NewForNetwork(ctx, network)
+	}
+	cred, err := credential(env, "private policies")
+	if err != nil {
+		return nil, err
+	}
+	return paymasterclient.NewPrivatePaymasterForNetworkWithCredential(ctx, network, cred, o.policy)
+}
+
+// sponsor creates the sponsor client.
+func (o *options) sponsor(ctx context.Context, env *environment) (sponsorclient.Client, error) {
+	if o.sponsorURL != "" && !apikey.HasPlaceholder(o.sponsorURL) {
+		return sponsorclient.New(ctx, o.sponsorURL)
+	}
+	cred, err := credential(env, "the sponsor API")
+	if err != nil {
+		return nil, err
+	}
+	if o.sponsorURL != "" {
+		return sponsorclient.NewWithCredential(ctx, o.sponsorURL, cred)
+	}
+	network, err := o.resolveNetwork()
+	if err != nil {
+		return nil, err
+	}
+	return sponsorclient.NewForNetworkWithCredential(ctx, network, cred)
+}
+
+// credential returns the API key of the environment, read from the file named by envAPIKeyFile if set.
+func credential(env *environment, purpose string) (apikey.Credential, error) {
+	if path := env.getenv(envAPIKeyFile); path != "" {
+		return apikey.FromFile(path), nil
+	}
+	if key := env.getenv(envAPIKey); key != "" {
+		return apikey.Static(key), nil
+	}
+	return nil, fmt.Errorf("%s or %s is required for %s", envAPIKey, envAPIKeyFile, purpose)
+}
+
+// table is the table form of an output, the first row holding the headers.
+type table [][]string
+
+// fields returns a two column table of field names and values.
+func fields(pairs ...string) table {
+	t := table{{"FIELD", "VALUE"}}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		t = append(t, []string{pairs[i], pairs[i+1]})
+	}
+	return t
+}
+
+// print writes v as JSON, or t as an aligned table, depending on the output format.
+func (o *options) print(env *environment, v interface{}, t table) error {
+	if o.output == "json" {
+		enc := json.NewEncoder(env.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	for _, row := range t {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
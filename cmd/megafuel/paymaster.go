@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/apikey"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/types"
+)
+
+// txFlags holds the flags describing a transaction.
+type txFlags struct {
+	to    string
+	value string
+	data  string
+	gas   uint64
+}
+
+func (f *txFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.to, "to", "", "recipient address")
+	fs.StringVar(&f.value, "value", "0", "value in wei")
+	fs.StringVar(&f.data, "data", "", "hex encoded call data")
+	fs.Uint64Var(&f.gas, "gas", 0, "gas limit, default 21000 for plain transfers")
+}
+
+func (f *txFlags) parse() (to *common.Address, value *big.Int, data []byte, err error) {
+	if f.to != "" {
+		if !common.IsHexAddress(f.to) {
+			return nil, nil, nil, fmt.Errorf("invalid recipient address %q", f.to)
+		}
+		address := common.HexToAddress(f.to)
+		to = &address
+	}
+	value, ok := new(big.Int).SetString(f.value, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, nil, nil, fmt.Errorf("invalid value %q", f.value)
+	}
+	if f.data != "" {
+		if data, err = hexutil.Decode(f.data); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid data: %w", err)
+		}
+	}
+	return to, value, data, nil
+}
+
+func runSponsorable(ctx context.Context, env *environment, args []string) error {
+	var (
+		o    options
+		tx   txFlags
+		from string
+	)
+	fs := newFlagSet(env, "sponsorable", "sponsorable [flags] -from <address> -to <address>", &o)
+	fs.StringVar(&from, "from", "", "sender address")
+	tx.register(fs)
+	if err := parse(fs, &o, args, 0, 0); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(from) {
+		fmt.Fprintf(env.stderr, "invalid sender address %q\n", from)
+		return errUsage
+	}
+	to, value, data, err := tx.parse()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	client, err := o.paymaster(ctx, env)
+	if err != nil {
+		return err
+	}
+	gas := hexutil.Uint64(tx.gas)
+	if gas == 0 && len(data) == 0 {
+		gas = 21000
+	}
+	input := hexutil.Bytes(data)
+	result, err := client.IsSponsorable(ctx, paymasterclient.TransactionArgs{
+		To:    to,
+		From:  common.HexToAddress(from),
+		Value: (*hexutil.Big)(value),
+		Gas:   &gas,
+		Data:  &input,
+	})
+	if err != nil {
+		return err
+	}
+	return o.print(env, result, fields(
+		"sponsorable", strconv.FormatBool(result.Sponsorable),
+		"sponsor name", result.SponsorName,
+		"sponsor website", result.SponsorWebsite,
+	))
+}
+
+func runSend(ctx context.Context, env *environment, args []string) error {
+	var (
+		o        options
+		tx       txFlags
+		keystore string
+		wait     bool
+	)
+	fs := newFlagSet(env, "send", "send [flags] -to <address>\n\nThe private key is read from "+envPrivateKey+
+		", or from a keystore file decrypted with "+envKeystorePassword+".", &o)
+	fs.StringVar(&keystore, "keystore", "", "keystore file of the sender")
+	fs.BoolVar(&wait, "wait", false, "wait until the transaction reaches a final status")
+	tx.register(fs)
+	if err := parse(fs, &o, args, 0, 0); err != nil {
+		return err
+	}
+	to, value, data, err := tx.parse()
+	if err != nil {
+		return err
+	}
+
+	var signer paymasterclient.Signer
+	if keystore != "" {
+		signer, err = paymasterclient.NewKeystoreFileSigner(keystore, env.getenv(envKeystorePassword))
+	} else if key := env.getenv(envPrivateKey); key != "" {
+		signer, err = paymasterclient.NewHexKeySigner(key)
+	} else {
+		err = fmt.Errorf("either -keystore or %s is required", envPrivateKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	client, err := o.paymaster(ctx, env)
+	if err != nil {
+		return err
+	}
+	result, err := paymasterclient.NewGaslessSender(client, signer, &paymasterclient.GaslessSenderOptions{UserAgent: "megafuel-cli"}).
+		Send(ctx, paymasterclient.GaslessRequest{To: to, Value: value, Data: data, Gas: tx.gas})
+	if errors.Is(err, paymasterclient.ErrNotSponsorable) && result != nil {
+		return fmt.Errorf("%w (reason: %s)", err, result.Reason)
+	}
+	if err != nil {
+		return err
+	}
+	if !wait {
+		return o.print(env, result, fields("tx hash", result.TxHash.Hex(), "from", signer.Address().Hex(), "nonce", strconv.FormatUint(result.Transaction.Nonce(), 10)))
+	}
+
+	final, err := paymasterclient.WaitForGaslessTransaction(ctx, client, result.TxHash, nil)
+	if err != nil {
+		return err
+	}
+	return printTransaction(env, &o, final.Transaction, final.Bundle)
+}
+
+func runStatus(ctx context.Context, env *environment, args []string) error {
+	var (
+		o    options
+		wait bool
+	)
+	fs := newFlagSet(env, "status", "status [flags] <tx hash>", &o)
+	fs.BoolVar(&wait, "wait", false, "wait until the transaction reaches a final status")
+	if err := parse(fs, &o, args, 1, 1); err != nil {
+		return err
+	}
+	txHash, err := parseHash(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	client, err := o.paymaster(ctx, env)
+	if err != nil {
+		return err
+	}
+	if wait {
+		final, err := paymasterclient.WaitForGaslessTransaction(ctx, client, txHash, nil)
+		if err != nil {
+			return err
+		}
+		return printTransaction(env, &o, final.Transaction, final.Bundle)
+	}
+	tx, err := client.GetGaslessTransactionByHash(ctx, txHash)
+	if err != nil {
+		return err
+	}
+	return printTransaction(env, &o, tx, nil)
+}
+
+func printTransaction(env *environment, o *options, tx *paymasterclient.TransactionResponse, bundle *paymasterclient.Bundle) error {
+	t := fields(
+		"tx hash", tx.TxHash.Hex(),
+		"status", tx.Status.String(),
+		"from", tx.FromAddress.Hex(),
+		"to", addressString(tx.ToAddress),
+		"nonce", strconv.FormatUint(tx.Nonce, 10),
+		"bundle", tx.BundleUUID.String(),
+		"gas used", strconv.FormatUint(tx.GasUsed, 10),
+		"gas fee", bigString(tx.GasFee),
+		"policy", tx.PolicyUUID.String(),
+		"born block", strconv.FormatInt(tx.BornBlockNumber, 10),
+	)
+	if bundle == nil {
+		return o.print(env, tx, t)
+	}
+	t = append(t, []string{"confirmed block", strconv.FormatInt(bundle.ConfirmedBlockNumber, 10)})
+	return o.print(env, paymasterclient.WaitResult{Transaction: tx, Bundle: bundle}, t)
+}
+
+func runBundle(ctx context.Context, env *environment, args []string) error {
+	var (
+		o      options
+		rpcURL string
+	)
+	fs := newFlagSet(env, "bundle", "bundle [flags] <bundle uuid> [tx hash...]\n\n"+
+		"The transactions of a confirmed bundle are read from its block on the chain node -rpc-url. Those of a bundle\n"+
+		"that is not confirmed yet cannot be listed; the given transactions are shown if they belong to it.", &o)
+	fs.StringVar(&rpcURL, "rpc-url", env.getenv(envRPCURL), "chain node endpoint the blocks of confirmed bundles are read from")
+	if err := parse(fs, &o, args, 1, -1); err != nil {
+		return err
+	}
+	if rpcURL == "" {
+		return fmt.Errorf("-rpc-url or %s is required", envRPCURL)
+	}
+	bundleUUID, err := uuid.FromString(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid bundle uuid %q", fs.Arg(0))
+	}
+	var txHashes []common.Hash
+	for _, arg := range fs.Args()[1:] {
+		txHash, err := parseHash(arg)
+		if err != nil {
+			return err
+		}
+		txHashes = append(txHashes, txHash)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	client, err := o.paymaster(ctx, env)
+	if err != nil {
+		return err
+	}
+	chain, err := ethclient.DialContext(ctx, rpcURL)
+	if err != nil {
+		return apikey.RedactError(err)
+	}
+	defer chain.Close()
+	view, err := paymasterclient.ExploreBundle(ctx, client, chain, bundleUUID, txHashes...)
+	if err != nil {
+		return err
+	}
+
+	out := bundleOutput{
+		Bundle:        view.Bundle,
+		SponsorTx:     view.SponsorTx,
+		Transactions:  []*paymasterclient.TransactionResponse{},
+		Unresolved:    make(map[common.Hash]string),
+		UserGasUsed:   view.UserGasUsed,
+		UserGasFee:    (*types.Big)(view.UserGasFee),
+		SponsorGasFee: (*types.Big)(view.SponsorGasFee),
+	}
+	t := table{{"LEG", "TX HASH", "FROM", "STATUS", "GAS USED", "GAS FEE"}}
+	if view.SponsorTx != nil {
+		t = append(t, []string{"sponsor", view.SponsorTx.TxHash.Hex(), view.SponsorTx.Address.Hex(), view.SponsorTx.Status.String(), "", view.SponsorGasFee.String()})
+	}
+	for _, tx := range view.Transactions {
+		out.Transactions = append(out.Transactions, tx.TransactionResponse)
+		t = append(t, []string{"user", tx.TxHash.Hex(), tx.FromAddress.Hex(), tx.Status.String(), strconv.FormatUint(tx.GasUsed, 10), bigString(tx.GasFee)})
+	}
+	t = append(t, []string{"total", "", "", view.Bundle.Status.String(), strconv.FormatUint(view.UserGasUsed, 10), view.UserGasFee.String()})
+	unresolved := make([]common.Hash, 0, len(view.Unresolved))
+	for txHash, err := range view.Unresolved {
+		out.Unresolved[txHash] = err.Error()
+		unresolved = append(unresolved, txHash)
+	}
+	sort.Slice(unresolved, func(i, j int) bool { return unresolved[i].Cmp(unresolved[j]) < 0 })
+	for _, txHash := range unresolved {
+		fmt.Fprintf(env.stderr, "warning: %s: %v\n", txHash.Hex(), view.Unresolved[txHash])
+	}
+	return o.print(env, out, t)
+}
+
+// bundleOutput is the JSON form of a paymasterclient.BundleView.
+type bundleOutput struct {
+	Bundle        *paymasterclient.Bundle                `json:"bundle"`
+	SponsorTx     *paymasterclient.SponsorTx             `json:"sponsorTx"`
+	Transactions  []*paymasterclient.TransactionResponse `json:"transactions"`
+	Unresolved    map[common.Hash]string                 `json:"unresolved,omitempty"`
+	UserGasUsed   uint64                                 `json:"userGasUsed"`
+	UserGasFee    *types.Big                             `json:"userGasFee"`
+	SponsorGasFee *types.Big                             `json:"sponsorGasFee"`
+}
+
+func parseHash(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid transaction hash %q", s)
+	}
+	return common.BytesToHash(b), nil
+}
+
+func addressString(address *common.Address) string {
+	if address == nil {
+		return ""
+	}
+	return address.Hex()
+}
+
+func bigString(b *types.Big) string {
+	if b == nil {
+		return ""
+	}
+	return b.Raw().String()
+}
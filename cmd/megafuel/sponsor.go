@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+func parsePolicy(o *options) (uuid.UUID, error) {
+	if o.policy == "" {
+		return uuid.Nil, fmt.Errorf("-policy is required")
+	}
+	policyUUID, err := uuid.FromString(o.policy)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid policy uuid %q", o.policy)
+	}
+	return policyUUID, nil
+}
+
+func runWhitelist(ctx context.Context, env *environment, args []string) error {
+	const usage = "whitelist <add|rm|list|empty> [flags] -policy <uuid> -type <type> [value...]\n\n" +
+		"Types: FromAccountWhitelist, ToAccountWhitelist, ContractMethodSigWhitelist, BEP20ReceiverWhiteList.\n" +
+		"Values of ContractMethodSigWhitelist may be selectors, method signatures, or method names of the -abi contract."
+	if len(args) == 0 {
+		fmt.Fprintf(env.stderr, "Usage: megafuel %s\n", usage)
+		return errUsage
+	}
+	action := args[0]
+
+	var (
+		o             options
+		whitelistType string
+		abiFile       string
+		offset, limit int
+		all           bool
+		chunkSize     int
+	)
+	fs := newFlagSet(env, "whitelist "+action, usage, &o)
+	fs.StringVar(&whitelistType, "type", "", "whitelist type")
+	fs.StringVar(&abiFile, "abi", "", "contract ABI file resolving the method names and selectors of ContractMethodSigWhitelist")
+	minArgs, maxArgs := 0, 0
+	switch action {
+	case "add", "rm":
+		minArgs, maxArgs = 1, -1
+		fs.IntVar(&chunkSize, "chunk-size", 0, "values per call, at most the server limit (required)")
+	case "list":
+		fs.IntVar(&offset, "offset", 0, "index of the first value listed")
+		fs.IntVar(&limit, "limit", 0, "maximum number of values listed, 0 for the server default")
+		fs.BoolVar(&all, "all", false, "list the whole whitelist page by page, -limit values per page")
+	case "empty":
+	default:
+		fmt.Fprintf(env.stderr, "unknown whitelist action %q\n", action)
+		fs.Usage()
+		return errUsage
+	}
+	if err := parse(fs, &o, args[1:], minArgs, maxArgs); err != nil {
+		return err
+	}
+	policyUUID, err := parsePolicy(&o)
+	if err != nil {
+		return err
+	}
+	if whitelistType == "" {
+		return fmt.Errorf("-type is required")
+	}
+	if (action == "add" || action == "rm") && chunkSize <= 0 {
+		return fmt.Errorf("-chunk-size is required")
+	}
+	var methods *sponsorclient.MethodSet
+	if abiFile != "" {
+		abiJSON, err := os.ReadFile(abiFile)
+		if err != nil {
+			return err
+		}
+		if methods, err = sponsorclient.ParseMethodSet(string(abiJSON)); err != nil {
+			return err
+		}
+	}
+	values := fs.Args()
+	if sponsorclient.WhitelistType(whitelistType) == sponsorclient.ContractMethodSigWhitelist {
+		if values, err = methods.Selectors(values...); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	client, err := o.sponsor(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	switch action {
+	case "add", "rm":
+		update := sponsorclient.AddToWhitelistChunked
+		if action == "rm" {
+			update = sponsorclient.RmFromWhitelistChunked
+		}
+		var report *sponsorclient.WhitelistReport
+		report, err = update(ctx, client, sponsorclient.WhiteListArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.WhitelistType(whitelistType), Values: values}, &sponsorclient.ChunkOptions{ChunkSize: chunkSize})
+		for _, value := range report.Failed() {
+			fmt.Fprintf(env.stderr, "not updated: %s\n", value)
+		}
+		ok = err == nil
+	case "empty":
+		ok, err = client.EmptyWhitelist(ctx, sponsorclient.EmptyWhiteListArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.WhitelistType(whitelistType)})
+	case "list":
+		listArgs := sponsorclient.GetWhitelistArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.WhitelistType(whitelistType), Offset: offset, Limit: limit}
+		var page *sponsorclient.WhitelistPage
+		if all {
+			page = &sponsorclient.WhitelistPage{Total: -1}
+			page.Values, err = sponsorclient.ListWhitelist(ctx, client, listArgs)
+		} else {
+			page, err = client.GetWhitelistPage(ctx, listArgs)
+		}
+		if err != nil {
+			return err
+		}
+		if methods == nil {
+			t := table{{"VALUE"}}
+			for _, value := range page.Values {
+				t = append(t, []string{value})
+			}
+			return o.print(env, page, t)
+		}
+		contractMethods := make([]sponsorclient.ContractMethod, len(page.Values))
+		t := table{{"SELECTOR", "SIGNATURE"}}
+		for i, selector := range page.Values {
+			contractMethods[i].Selector = selector
+			contractMethods[i].Signature, _ = methods.Signature(selector)
+			t = append(t, []string{selector, contractMethods[i].Signature})
+		}
+		return o.print(env, contractMethods, t)
+	}
+	if err != nil {
+		return err
+	}
+	return o.print(env, map[string]bool{"success": ok}, fields("success", strconv.FormatBool(ok)))
+}
+
+func runSpend(ctx context.Context, env *environment, args []string) error {
+	const usage = "spend <user|policy> [flags] -policy <uuid> [user address]"
+	if len(args) == 0 || args[0] != "user" && args[0] != "policy" {
+		fmt.Fprintf(env.stderr, "Usage: megafuel %s\n", usage)
+		return errUsage
+	}
+	action := args[0]
+
+	var o options
+	fs := newFlagSet(env, "spend "+action, usage, &o)
+	nargs := 0
+	if action == "user" {
+		nargs = 1
+	}
+	if err := parse(fs, &o, args[1:], nargs, nargs); err != nil {
+		return err
+	}
+	policyUUID, err := parsePolicy(&o)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	client, err := o.sponsor(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	if action == "policy" {
+		data, err := client.GetPolicySpendData(ctx, policyUUID)
+		if err != nil {
+			return err
+		}
+		return o.print(env, data, fields(
+			"cost", bigString(data.Cost),
+			"updated at", strconv.FormatUint(data.UpdateAt, 10),
+			"chain id", strconv.Itoa(data.ChainID),
+		))
+	}
+
+	if !common.IsHexAddress(fs.Arg(0)) {
+		return fmt.Errorf("invalid user address %q", fs.Arg(0))
+	}
+	data, err := client.GetUserSpendData(ctx, common.HexToAddress(fs.Arg(0)), policyUUID)
+	if err != nil {
+		return err
+	}
+	return o.print(env, data, fields(
+		"user", data.UserAddress.Hex(),
+		"gas cost", bigString(data.GasCost),
+		"gas cost today", bigString(data.GasCostCurDay),
+		"tx count today", strconv.FormatUint(data.TxCountCurDay, 10),
+		"updated at", strconv.FormatUint(data.UpdateAt, 10),
+		"chain id", strconv.Itoa(data.ChainID),
+	))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/whitelistsync"
+)
+
+func runSync(ctx context.Context, env *environment, args []string) error {
+	var (
+		o         options
+		dryRun    bool
+		yes       bool
+		chunkSize int
+	)
+	fs := newFlagSet(env, "sync", "sync [flags] <config file>\n\n"+
+		"The config file maps policy UUIDs to whitelist types to values, in YAML or JSON. Whitelists that are not\n"+
+		"listed are left alone, an empty list empties the whitelist. The plan is printed and applied once confirmed.", &o)
+	fs.BoolVar(&dryRun, "dry-run", false, "print the plan without applying it")
+	fs.BoolVar(&yes, "yes", false, "apply the plan without asking for confirmation")
+	fs.IntVar(&chunkSize, "chunk-size", 0, "values per call, at most the server limit (required to add or remove values)")
+	if err := parse(fs, &o, args, 1, 1); err != nil {
+		return err
+	}
+	desired, err := whitelistsync.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	client, plan, err := syncPlan(ctx, env, &o, desired)
+	if err != nil {
+		return err
+	}
+	// The plan goes to stdout in table mode; in json mode it is printed as JSON at the end, so show it on
+	// stderr when asking for confirmation.
+	switch {
+	case o.output == "table":
+		_, err = plan.WriteTo(env.stdout)
+	case !dryRun && !yes && !plan.IsEmpty():
+		_, err = plan.WriteTo(env.stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !dryRun && !plan.IsEmpty() {
+		if plan.IsChunked() && chunkSize <= 0 {
+			return fmt.Errorf("-chunk-size is required to add or remove values")
+		}
+		if !yes && !confirm(env) {
+			fmt.Fprintln(env.stderr, "Not applied.")
+			return nil
+		}
+		// The apply gets its own timeout, the user may take a while to answer.
+		applyCtx, cancel := context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+		err = whitelistsync.Apply(applyCtx, client, plan, &sponsorclient.ChunkOptions{ChunkSize: chunkSize})
+	}
+	if o.output == "json" {
+		if printErr := o.print(env, plan, nil); printErr != nil && err == nil {
+			err = printErr
+		}
+	}
+	return err
+}
+
+// syncPlan creates the sponsor client, reads the current whitelists and plans the changes reaching desired,
+// within the command timeout.
+func syncPlan(ctx context.Context, env *environment, o *options, desired whitelistsync.Config) (sponsorclient.Client, *whitelistsync.Plan, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	client, err := o.sponsor(ctx, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan, err := whitelistsync.NewPlan(ctx, client, desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, plan, nil
+}
+
+// confirm asks the user whether to apply the plan, anything but yes declines.
+func confirm(env *environment) bool {
+	fmt.Fprint(env.stderr, "Apply these changes? [y/N] ")
+	answer, err := bufio.NewReader(env.stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,142 @@
+// Package apikey keeps NodeReal API keys out of URLs, logs and errors.
+//
+// The open-platform endpoints take the API key in their path, e.g.
+// https://open-platform-ap.nodereal.io/{apikey}/megafuel. The clients created with a Credential dial the
+// URL template and a Transport puts the current key in place of networks.APIKeyPlaceholder in each
+// request, so the key is never part of the URL the clients know and report. Keys read from an environment
+// variable or a file are read again when they change, so they can be rotated without restarting.
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
+)
+
+// ErrNoAPIKey is returned by a Credential that has no API key.
+var ErrNoAPIKey = errors.New("no api key")
+
+// Credential supplies the current API key. It is called for every request and must be safe for concurrent use.
+type Credential interface {
+	APIKey() (string, error)
+}
+
+// Static is a fixed API key. It prints redacted.
+type Static string
+
+// APIKey returns the key.
+func (k Static) APIKey() (string, error) {
+	if k == "" {
+		return "", ErrNoAPIKey
+	}
+	return string(k), nil
+}
+
+func (k Static) String() string {
+	return networks.APIKeyPlaceholder
+}
+
+// GoString keeps the key out of %#v.
+func (k Static) GoString() string {
+	return `apikey.Static("` + networks.APIKeyPlaceholder + `")`
+}
+
+type envCredential string
+
+// FromEnv returns a Credential reading the API key from the environment variable name at every request.
+func FromEnv(name string) Credential {
+	return envCredential(name)
+}
+
+func (name envCredential) APIKey() (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(name)))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s is not set", ErrNoAPIKey, string(name))
+	}
+	return key, nil
+}
+
+type fileCredential struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// FromFile returns a Credential reading the API key from the file at path, surrounding whitespace trimmed.
+// The file is read again when its modification time or size changes, e.g. when a secret is rotated.
+func FromFile(path string) Credential {
+	return &fileCredential{path: path}
+}
+
+func (f *fileCredential) APIKey() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read api key: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.key != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.key, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read api key: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrNoAPIKey, f.path)
+	}
+	f.key, f.modTime, f.size = key, info.ModTime(), info.Size()
+	return key, nil
+}
+
+// Transport is an http.RoundTripper putting the API key of Credential in place of networks.APIKeyPlaceholder
+// in the path of each request. paymasterclient and sponsorclient install it when created with a Credential;
+// wrap the transport of a custom http.Client passed with rpc.WithHTTPClient to keep it.
+type Transport struct {
+	Credential Credential
+	// Base is the underlying RoundTripper. Nil means http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// NewTransport returns a Transport over base for cred.
+func NewTransport(cred Credential, base http.RoundTripper) *Transport {
+	return &Transport{Credential: cred, Base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !strings.Contains(req.URL.Path, networks.APIKeyPlaceholder) {
+		return base.RoundTrip(req)
+	}
+	key, err := t.Credential.APIKey()
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	// The request is cloned so that the caller, and the errors it reports, keep the URL without the key.
+	req = req.Clone(req.Context())
+	req.URL.Path = strings.ReplaceAll(req.URL.Path, networks.APIKeyPlaceholder, key)
+	req.URL.RawPath = ""
+	return base.RoundTrip(req)
+}
+
+// HasPlaceholder reports whether urlTemplate has the networks.APIKeyPlaceholder slot of the API key.
+func HasPlaceholder(urlTemplate string) bool {
+	return strings.Contains(urlTemplate, networks.APIKeyPlaceholder)
+}
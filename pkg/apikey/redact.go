@@ -0,0 +1,125 @@
+package apikey
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
+)
+
+// urlPattern matches the URLs embedded in a message, e.g. by a *url.Error.
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// RedactURL returns u without credentials and query, and with every API key in its path replaced by
+// networks.APIKeyPlaceholder. A path segment is an API key if it is one of keys or looks like one,
+// i.e. is made of 32 or more alphanumeric characters.
+func RedactURL(u *url.URL, keys ...string) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	segments := strings.Split(redacted.Path, "/")
+	for i, segment := range segments {
+		if looksLikeAPIKey(segment) || contains(keys, segment) {
+			segments[i] = networks.APIKeyPlaceholder
+		}
+	}
+	redacted.Path = strings.Join(segments, "/")
+	redacted.RawPath = ""
+	return strings.ReplaceAll(redacted.String(), url.PathEscape(networks.APIKeyPlaceholder), networks.APIKeyPlaceholder)
+}
+
+// Redact returns s with the URLs it contains redacted like RedactURL and every occurrence of keys replaced
+// by networks.APIKeyPlaceholder. URLs that do not parse, like the one quoted by a url.Parse error, still get the
+// API keys of their path replaced.
+func Redact(s string, keys ...string) string {
+	s = urlPattern.ReplaceAllStringFunc(s, func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return redactSegments(raw, keys)
+		}
+		return RedactURL(u, keys...)
+	})
+	for _, key := range keys {
+		if key != "" {
+			s = strings.ReplaceAll(s, key, networks.APIKeyPlaceholder)
+		}
+	}
+	return s
+}
+
+// URL is a URL printing redacted, see RedactURL. Use it to log endpoints.
+type URL string
+
+func (u URL) String() string {
+	parsed, err := url.Parse(string(u))
+	if err != nil {
+		return Redact(string(u))
+	}
+	return RedactURL(parsed)
+}
+
+// GoString keeps the API key out of %#v.
+func (u URL) GoString() string {
+	return `apikey.URL("` + u.String() + `")`
+}
+
+// MarshalText marshals the redacted URL.
+func (u URL) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+type redactedError struct {
+	err  error
+	keys []string
+}
+
+// RedactError returns err with its message redacted like Redact, nil if err is nil. The returned error
+// wraps err, so errors.Is and errors.As still see through it.
+func RedactError(err error, keys ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err, keys}
+}
+
+func (e *redactedError) Error() string {
+	return Redact(e.err.Error(), e.keys...)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactSegments replaces the API keys among the slash separated segments of raw, a URL that does not parse.
+func redactSegments(raw string, keys []string) string {
+	segments := strings.Split(raw, "/")
+	for i, segment := range segments {
+		if looksLikeAPIKey(segment) || contains(keys, segment) {
+			segments[i] = networks.APIKeyPlaceholder
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// looksLikeAPIKey reports whether a path segment looks like a NodeReal API key, 32 or more alphanumeric characters.
+func looksLikeAPIKey(segment string) bool {
+	if len(segment) < 32 {
+		return false
+	}
+	for _, c := range segment {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v != "" && v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,367 @@
+package megafueltest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+	mftypes "github.com/node-real/megafuel-go-sdk/pkg/types"
+)
+
+func contextWithHeader(r *http.Request) context.Context {
+	return context.WithValue(r.Context(), headerKey{}, r.Header)
+}
+
+// policyOf returns the policy of a call: the private policy header if set, the default policy otherwise.
+func (s *Server) policyOf(ctx context.Context) uuid.UUID {
+	if h, ok := ctx.Value(headerKey{}).(http.Header); ok {
+		if policyUUID, err := uuid.FromString(h.Get(headerPolicyUUID)); err == nil {
+			return policyUUID
+		}
+	}
+	return s.opts.DefaultPolicyUUID
+}
+
+// ethAPI implements the eth namespace.
+type ethAPI struct{ s *Server }
+
+func (api *ethAPI) ChainId() (*hexutil.Big, error) {
+	api.s.mu.Lock()
+	defer api.s.mu.Unlock()
+	if err := api.s.popFailure("eth_chainId"); err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(big.NewInt(api.s.opts.ChainID)), nil
+}
+
+func (api *ethAPI) GetTransactionCount(address common.Address, _ rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	api.s.mu.Lock()
+	defer api.s.mu.Unlock()
+	if err := api.s.popFailure("eth_getTransactionCount"); err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(api.s.nonces[address]), nil
+}
+
+// GetBlockByNumber returns the block mined by confirming a bundle, holding its sponsor transaction followed
+// by its user transactions. Other blocks up to the current one are empty, later ones do not exist.
+func (api *ethAPI) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("eth_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+
+	n := number.Int64()
+	if number < 0 {
+		n = s.blockNumber
+	}
+	if n > s.blockNumber {
+		return nil, nil
+	}
+	txs := s.blocks[n]
+	block := types.NewBlock(&types.Header{
+		Number:     big.NewInt(n),
+		Difficulty: new(big.Int),
+		GasLimit:   30_000_000,
+		Extra:      []byte{},
+	}, &types.Body{Transactions: txs}, nil, trie.NewStackTrie(nil))
+
+	data, err := json.Marshal(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fullTx {
+		fields["transactions"] = block.Transactions()
+	} else {
+		hashes := make([]common.Hash, len(txs))
+		for i, tx := range txs {
+			hashes[i] = tx.Hash()
+		}
+		fields["transactions"] = hashes
+	}
+	fields["uncles"] = []common.Hash{}
+	return fields, nil
+}
+
+func (api *ethAPI) SendRawTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("eth_sendRawTransaction"); err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, &Error{Code: -32602, Message: fmt.Sprintf("invalid raw transaction: %v", err)}
+	}
+	if tx.ChainId().Sign() != 0 && tx.ChainId().Int64() != s.opts.ChainID {
+		return common.Hash{}, fmt.Errorf("invalid chain id %v", tx.ChainId())
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(s.opts.ChainID)), tx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid sender: %v", err)
+	}
+	if tx.GasPrice().Sign() != 0 || tx.GasFeeCap().Sign() != 0 {
+		return common.Hash{}, errors.New("gas price of a gasless transaction must be zero")
+	}
+	if _, ok := s.txs[tx.Hash()]; ok {
+		return common.Hash{}, errors.New("already known")
+	}
+
+	used := s.usedNonces[from]
+	if used == nil {
+		used = make(map[uint64]bool)
+		s.usedNonces[from] = used
+	}
+	if used[tx.Nonce()] || tx.Nonce() < s.baseNonces[from] {
+		return common.Hash{}, errors.New("nonce too low")
+	}
+
+	args, err := paymasterclient.NewTransactionArgs(from, tx)
+	if err != nil {
+		return common.Hash{}, &Error{Code: -32602, Message: err.Error()}
+	}
+	policyUUID := s.policyOf(ctx)
+	sponsorable, err := s.isSponsorable(policyUUID, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if !sponsorable.Sponsorable {
+		return common.Hash{}, errors.New("transaction is not sponsorable")
+	}
+
+	used[tx.Nonce()] = true
+	if !s.staleNonces {
+		s.nonces[from] = max(s.nonces[from], tx.Nonce()+1)
+	}
+	s.sent = append(s.sent, tx)
+
+	bundle := s.bundleFor(tx)
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), s.opts.SponsorGasPrice)
+	s.txs[tx.Hash()] = &paymasterclient.TransactionResponse{
+		TxHash:          tx.Hash(),
+		BundleUUID:      bundle.bundle.BundleUUID,
+		FromAddress:     from,
+		ToAddress:       tx.To(),
+		Nonce:           tx.Nonce(),
+		RawData:         input,
+		Status:          paymasterclient.StatusNew,
+		GasFee:          (*mftypes.Big)(fee),
+		PolicyUUID:      policyUUID,
+		Source:          userAgentOf(ctx),
+		BornBlockNumber: s.blockNumber,
+		ChainID:         int(s.opts.ChainID),
+	}
+	s.txGas[tx.Hash()] = tx.Gas()
+	s.addSpend(policyUUID, from, fee)
+	return tx.Hash(), nil
+}
+
+func userAgentOf(ctx context.Context) string {
+	if h, ok := ctx.Value(headerKey{}).(http.Header); ok {
+		return h.Get("User-Agent")
+	}
+	return ""
+}
+
+// bundleFor puts tx into the open bundle, opening a new one if needed. It must be called with s.mu held.
+func (s *Server) bundleFor(tx *types.Transaction) *bundleState {
+	b := s.openBundle
+	if b == nil || len(b.txHashes) >= s.opts.BundleSize || b.bundle.Status != paymasterclient.StatusNew {
+		bundleUUID := uuid.Must(uuid.NewV4())
+		// The sponsor pays for the bundle with a transfer to itself, unsigned as the fake has no sponsor key.
+		sponsorRawTx := types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(len(s.bundles)),
+			GasPrice: new(big.Int).Set(s.opts.SponsorGasPrice),
+			Gas:      21000,
+			To:       &SponsorAddress,
+			Data:     bundleUUID.Bytes(),
+		})
+		sponsorTxHash := sponsorRawTx.Hash()
+		b = &bundleState{
+			bundle: paymasterclient.Bundle{
+				BundleUUID:      bundleUUID,
+				Status:          paymasterclient.StatusNew,
+				AvgGasPrice:     (*mftypes.Big)(new(big.Int).Set(s.opts.SponsorGasPrice)),
+				BornBlockNumber: s.blockNumber,
+				ChainID:         int(s.opts.ChainID),
+			},
+			sponsorTx: paymasterclient.SponsorTx{
+				TxHash:          sponsorTxHash,
+				Address:         SponsorAddress,
+				BundleUUID:      bundleUUID,
+				Status:          paymasterclient.StatusNew,
+				GasPrice:        (*mftypes.Big)(new(big.Int).Set(s.opts.SponsorGasPrice)),
+				GasFee:          (*mftypes.Big)(new(big.Int)),
+				BornBlockNumber: s.blockNumber,
+				ChainID:         int(s.opts.ChainID),
+			},
+			sponsorRawTx: sponsorRawTx,
+		}
+		s.bundles[bundleUUID] = b
+		s.sponsorTxs[sponsorTxHash] = b
+		s.openBundle = b
+	}
+	b.txHashes = append(b.txHashes, tx.Hash())
+	b.txs = append(b.txs, tx)
+	return b
+}
+
+// addSpend accounts fee to the policy and the user. It must be called with s.mu held.
+func (s *Server) addSpend(policyUUID uuid.UUID, from common.Address, fee *big.Int) {
+	now := uint64(time.Now().Unix())
+
+	policy := s.policySpend[policyUUID]
+	if policy == nil {
+		policy = &sponsorclient.PolicySpendData{Cost: (*mftypes.Big)(new(big.Int)), ChainID: int(s.opts.ChainID)}
+		s.policySpend[policyUUID] = policy
+	}
+	policy.Cost.Raw().Add(policy.Cost.Raw(), fee)
+	policy.UpdateAt = now
+
+	users := s.userSpend[policyUUID]
+	if users == nil {
+		users = make(map[common.Address]*sponsorclient.UserSpendData)
+		s.userSpend[policyUUID] = users
+	}
+	user := users[from]
+	if user == nil {
+		user = &sponsorclient.UserSpendData{
+			UserAddress:   from,
+			GasCost:       (*mftypes.Big)(new(big.Int)),
+			GasCostCurDay: (*mftypes.Big)(new(big.Int)),
+			ChainID:       int(s.opts.ChainID),
+		}
+		users[from] = user
+	}
+	user.GasCost.Raw().Add(user.GasCost.Raw(), fee)
+	user.GasCostCurDay.Raw().Add(user.GasCostCurDay.Raw(), fee)
+	user.TxCountCurDay++
+	user.UpdateAt = now
+}
+
+func (api *ethAPI) GetGaslessTransactionByHash(txHash common.Hash) (*paymasterclient.TransactionResponse, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("eth_getGaslessTransactionByHash"); err != nil {
+		return nil, err
+	}
+
+	tx, ok := s.txs[txHash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", txHash)
+	}
+	if script := s.scripts[txHash]; len(script) > 0 {
+		s.scripts[txHash] = script[1:]
+		s.setStatus(txHash, script[0])
+	}
+	txCopy := *tx
+	return &txCopy, nil
+}
+
+// pmAPI implements the pm namespace, shared by the paymaster and the sponsor API.
+type pmAPI struct{ s *Server }
+
+func (api *pmAPI) IsSponsorable(ctx context.Context, args paymasterclient.TransactionArgs) (*paymasterclient.IsSponsorableResponse, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_isSponsorable"); err != nil {
+		return nil, err
+	}
+	return s.isSponsorable(s.policyOf(ctx), args)
+}
+
+// isSponsorable must be called with s.mu held.
+func (s *Server) isSponsorable(policyUUID uuid.UUID, args paymasterclient.TransactionArgs) (*paymasterclient.IsSponsorableResponse, error) {
+	if s.sponsorable != nil {
+		return s.sponsorable(policyUUID, args)
+	}
+
+	lists := s.whitelists[policyUUID]
+	allowed := func(t sponsorclient.WhitelistType, value string) bool {
+		values := lists[t]
+		return len(values) == 0 || indexFold(values, value) >= 0
+	}
+	ok := allowed(sponsorclient.FromAccountWhitelist, args.From.Hex())
+	if args.To != nil {
+		ok = ok && allowed(sponsorclient.ToAccountWhitelist, args.To.Hex())
+	}
+	if args.Data != nil && len(*args.Data) >= 4 {
+		ok = ok && allowed(sponsorclient.ContractMethodSigWhitelist, hexutil.Encode((*args.Data)[:4]))
+	}
+	if !ok {
+		return &paymasterclient.IsSponsorableResponse{Sponsorable: false}, nil
+	}
+	return &paymasterclient.IsSponsorableResponse{
+		Sponsorable:    true,
+		SponsorName:    "megafueltest",
+		SponsorWebsite: "https://megafuel.test",
+	}, nil
+}
+
+func (api *pmAPI) GetSponsorTxByTxHash(txHash common.Hash) (*paymasterclient.SponsorTx, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_getSponsorTxByTxHash"); err != nil {
+		return nil, err
+	}
+	b, ok := s.sponsorTxs[txHash]
+	if !ok {
+		return nil, fmt.Errorf("sponsor transaction %s not found", txHash)
+	}
+	sponsorTx := b.sponsorTx
+	return &sponsorTx, nil
+}
+
+func (api *pmAPI) GetSponsorTxByBundleUuid(bundleUUID uuid.UUID) (*paymasterclient.SponsorTx, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_getSponsorTxByBundleUuid"); err != nil {
+		return nil, err
+	}
+	b, ok := s.bundles[bundleUUID]
+	if !ok {
+		return nil, fmt.Errorf("bundle %s not found", bundleUUID)
+	}
+	sponsorTx := b.sponsorTx
+	return &sponsorTx, nil
+}
+
+func (api *pmAPI) GetBundleByUuid(bundleUUID uuid.UUID) (*paymasterclient.Bundle, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_getBundleByUuid"); err != nil {
+		return nil, err
+	}
+	b, ok := s.bundles[bundleUUID]
+	if !ok {
+		return nil, fmt.Errorf("bundle %s not found", bundleUUID)
+	}
+	bundle := b.bundle
+	return &bundle, nil
+}
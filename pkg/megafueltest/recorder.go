@@ -0,0 +1,333 @@
+package megafueltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/apikey"
+	"github.com/node-real/megafuel-go-sdk/pkg/retry"
+)
+
+// ErrNoInteraction is returned in replay mode for calls missing from the fixture.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// RecorderMode selects whether a Recorder records or replays.
+type RecorderMode int
+
+const (
+	// ModeReplay answers calls from the fixture file without network access.
+	ModeReplay RecorderMode = iota
+	// ModeRecord forwards calls to the endpoint and records them for Save.
+	ModeRecord
+)
+
+// RecorderOptions defines the options for NewRecorder.
+type RecorderOptions struct {
+	// Mode selects recording or replaying. Default value is ModeReplay.
+	Mode RecorderMode
+	// Transport is the RoundTripper recorded calls are forwarded to. Default value is a retry.Transport
+	// over http.DefaultTransport, like the one installed by the clients.
+	Transport http.RoundTripper
+	// APIKeys lists API keys to redact from the recorded URLs, in addition to path segments that look like one.
+	APIKeys []string
+}
+
+// Interaction is a recorded JSON-RPC call.
+type Interaction struct {
+	URL    string          `json:"url"` // URL is the endpoint called, with API keys redacted.
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	// Status is the HTTP status of the response. Body holds the response body for statuses other than 200,
+	// Result or Error the JSON-RPC response otherwise.
+	Status int             `json:"status"`
+	Body   string          `json:"body,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+type fixture struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper recording JSON-RPC calls to a fixture file and replaying them later,
+// for deterministic client tests. Install it with ClientOption:
+//
+//	recorder, err := megafueltest.NewRecorder("testdata/send.json", &megafueltest.RecorderOptions{Mode: mode})
+//	client, err := paymasterclient.New(ctx, url, recorder.ClientOption())
+//
+// The clients created with an apikey.Credential need their transport to stay under apikey.Transport,
+// so put the recorder below it with Transport instead:
+//
+//	httpClient := &http.Client{Transport: apikey.NewTransport(cred, recorder.Transport(nil))}
+//	client, err := sponsorclient.NewWithCredential(ctx, urlTemplate, cred, rpc.WithHTTPClient(httpClient))
+//
+// Replay matches calls on method and params only, so request IDs, headers and the endpoint URL may change
+// between recording and replay. Identical calls are answered in recorded order and the last answer repeats
+// once they are exhausted, so polling loops replay as recorded. Batch requests are matched call by call.
+type Recorder struct {
+	path string
+	opts RecorderOptions
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         map[*Interaction]bool
+}
+
+// NewRecorder creates a Recorder for the fixture file at path. In replay mode the fixture is loaded
+// immediately; in record mode it is written by Save.
+func NewRecorder(path string, opts *RecorderOptions) (*Recorder, error) {
+	r := &Recorder{path: path, used: make(map[*Interaction]bool)}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Transport == nil {
+		r.opts.Transport = retry.NewTransport(nil)
+	}
+	if r.opts.Mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+		}
+		// Params are indented in the file, and may have been edited by hand.
+		for _, in := range f.Interactions {
+			in.Params = canonicalJSON(in.Params)
+		}
+		r.interactions = f.Interactions
+	}
+	return r, nil
+}
+
+// ClientOption returns an option making paymasterclient.New or sponsorclient.New send their calls through r.
+// It replaces the transport of the client, use Transport with the constructors taking an apikey.Credential.
+func (r *Recorder) ClientOption() rpc.ClientOption {
+	return rpc.WithHTTPClient(&http.Client{Transport: r})
+}
+
+// Transport returns an http.RoundTripper recording or replaying through r that forwards recorded calls to
+// base instead of RecorderOptions.Transport. Nil base means RecorderOptions.Transport. Wrap it with
+// apikey.NewTransport for the clients created with an apikey.Credential; the key is then redacted from
+// the recorded URLs like any other.
+func (r *Recorder) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = r.opts.Transport
+	}
+	return &recorderTransport{recorder: r, base: base}
+}
+
+type recorderTransport struct {
+	recorder *Recorder
+	base     http.RoundTripper
+}
+
+func (t *recorderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.recorder.roundTrip(req, t.base)
+}
+
+// Interactions returns the calls recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interactions := make([]Interaction, len(r.interactions))
+	for i, in := range r.interactions {
+		interactions[i] = *in
+	}
+	return interactions
+}
+
+// Save writes the recorded calls to the fixture file. It does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.opts.Mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(fixture{Interactions: r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// jsonrpcMessage is a JSON-RPC request or response.
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// parseMessages parses a single or batch JSON-RPC body.
+func parseMessages(body []byte) ([]*jsonrpcMessage, bool, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []*jsonrpcMessage
+		err := json.Unmarshal(body, &batch)
+		return batch, true, err
+	}
+	var msg jsonrpcMessage
+	err := json.Unmarshal(body, &msg)
+	return []*jsonrpcMessage{&msg}, false, err
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	return r.roundTrip(req, r.opts.Transport)
+}
+
+// roundTrip records req, forwarding it to base, or replays it.
+func (r *Recorder) roundTrip(req *http.Request, base http.RoundTripper) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	calls, batch, err := parseMessages(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON-RPC request: %w", err)
+	}
+	if r.opts.Mode == ModeReplay {
+		return r.replay(req, calls, batch)
+	}
+	return r.record(req, body, calls, base)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, calls []*jsonrpcMessage, base http.RoundTripper) (*http.Response, error) {
+	forward := req.Clone(req.Context())
+	forward.Body = io.NopCloser(bytes.NewReader(body))
+	forward.ContentLength = int64(len(body))
+	resp, err := base.RoundTrip(forward)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	responses := make(map[string]*jsonrpcMessage)
+	if resp.StatusCode == http.StatusOK {
+		msgs, _, err := parseMessages(respBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON-RPC response: %w", err)
+		}
+		for _, msg := range msgs {
+			responses[string(msg.ID)] = msg
+		}
+	}
+
+	endpoint := apikey.RedactURL(req.URL, r.opts.APIKeys...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, call := range calls {
+		in := &Interaction{URL: endpoint, Method: call.Method, Params: canonicalJSON(call.Params), Status: resp.StatusCode}
+		if resp.StatusCode != http.StatusOK {
+			in.Body = string(respBody)
+		} else if msg, ok := responses[string(call.ID)]; ok {
+			in.Result, in.Error = msg.Result, msg.Error
+		}
+		r.interactions = append(r.interactions, in)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, calls []*jsonrpcMessage, batch bool) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	responses := make([]*jsonrpcMessage, len(calls))
+	for i, call := range calls {
+		in := r.match(call.Method, canonicalJSON(call.Params))
+		if in == nil {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, call.Method, call.Params)
+		}
+		if in.Status != 0 && in.Status != http.StatusOK {
+			return newResponse(req, in.Status, []byte(in.Body)), nil
+		}
+		responses[i] = &jsonrpcMessage{Version: "2.0", ID: call.ID, Result: in.Result, Error: in.Error}
+		if in.Result == nil && in.Error == nil {
+			responses[i].Result = json.RawMessage("null")
+		}
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+	if batch {
+		body, err = json.Marshal(responses)
+	} else {
+		body, err = json.Marshal(responses[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(req, http.StatusOK, body), nil
+}
+
+// match returns the first unused interaction for the call, or the last used one. It must be called with r.mu held.
+func (r *Recorder) match(method string, params json.RawMessage) *Interaction {
+	var last *Interaction
+	for _, in := range r.interactions {
+		if in.Method != method || !bytes.Equal(in.Params, params) {
+			continue
+		}
+		if !r.used[in] {
+			r.used[in] = true
+			return in
+		}
+		last = in
+	}
+	return last
+}
+
+func newResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// canonicalJSON re-encodes raw so that equal values compare equal byte for byte. Numbers keep their digits,
+// so that distinct values beyond the precision of a float64 stay distinct.
+func canonicalJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return raw
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return raw
+	}
+	return canonical
+}
@@ -0,0 +1,273 @@
+// Package megafueltest provides an in-process fake MegaFuel server for offline tests.
+//
+// The server speaks JSON-RPC over HTTP like the real paymaster and sponsor endpoints, so clients
+// created with paymasterclient.New and sponsorclient.New can be pointed at its URL. All state is
+// kept in memory and can be inspected and scripted, e.g. to move transactions through statuses,
+// to make the next call of a method fail or to decide which transactions are sponsorable.
+//
+// It also serves the blocks including the confirmed bundles, with their sponsor and user transactions,
+// so it can stand in for a node of the chain, e.g. for paymasterclient.ExploreBundle.
+package megafueltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+const (
+	headerPolicyUUID = "X-MegaFuel-Policy-Uuid"
+	defaultChainID   = 97
+)
+
+// defaultSponsorGasPrice is the gas price of the sponsor transactions, 1 gwei.
+var defaultSponsorGasPrice = big.NewInt(1_000_000_000)
+
+// SponsorAddress is the account sending the sponsor transactions.
+var SponsorAddress = common.HexToAddress("0x000000000000000000000000000000000000fEE1")
+
+// Options defines the options for NewServer.
+type Options struct {
+	// ChainID is the chain ID served. Default value is 97.
+	ChainID int64
+	// DefaultPolicyUUID is the policy of calls without the private policy header.
+	DefaultPolicyUUID uuid.UUID
+	// SponsorGasPrice is the gas price paid by the sponsor. Default value is 1 gwei.
+	SponsorGasPrice *big.Int
+	// BundleSize is the number of transactions put into a bundle before opening a new one. Default value is 1.
+	BundleSize int
+	// WhitelistBatchSize is the maximum number of values of a whitelist update. Default value is no limit.
+	WhitelistBatchSize int
+}
+
+// SponsorableFunc decides whether tx is sponsorable by the given policy.
+type SponsorableFunc func(policyUUID uuid.UUID, tx paymasterclient.TransactionArgs) (*paymasterclient.IsSponsorableResponse, error)
+
+// Request is an HTTP request received by the server.
+type Request struct {
+	Methods []string    // Methods holds the JSON-RPC methods called, several for a batch request.
+	Header  http.Header // Header holds the HTTP headers of the request.
+	Path    string      // Path is the URL path of the request, which holds the API key on the real endpoints.
+}
+
+// Server is a fake MegaFuel endpoint serving both the paymaster and the sponsor API.
+type Server struct {
+	// URL is the base URL of the server, of the form http://ipaddr:port with no trailing slash.
+	URL string
+
+	opts       Options
+	httpServer *httptest.Server
+	rpcServer  *rpc.Server
+
+	mu          sync.Mutex
+	httpStatus  int
+	requests    []Request
+	failures    map[string][]error
+	sponsorable SponsorableFunc
+	staleNonces bool
+	nonces      map[common.Address]uint64
+	baseNonces  map[common.Address]uint64
+	usedNonces  map[common.Address]map[uint64]bool
+	sent        []*types.Transaction
+	txs         map[common.Hash]*paymasterclient.TransactionResponse
+	txGas       map[common.Hash]uint64
+	bundles     map[uuid.UUID]*bundleState
+	openBundle  *bundleState
+	sponsorTxs  map[common.Hash]*bundleState
+	scripts     map[common.Hash][]paymasterclient.Status
+	blockNumber int64
+	blocks      map[int64][]*types.Transaction
+	whitelists  map[uuid.UUID]map[sponsorclient.WhitelistType][]string
+	userSpend   map[uuid.UUID]map[common.Address]*sponsorclient.UserSpendData
+	policySpend map[uuid.UUID]*sponsorclient.PolicySpendData
+}
+
+type bundleState struct {
+	bundle    paymasterclient.Bundle
+	sponsorTx paymasterclient.SponsorTx
+	// sponsorRawTx is the transaction of sponsorTx, put into the block of the bundle before txs.
+	sponsorRawTx *types.Transaction
+	txHashes     []common.Hash
+	txs          []*types.Transaction
+}
+
+// NewServer starts a Server. The caller should call Close when finished, to shut it down.
+func NewServer(opts *Options) *Server {
+	s := &Server{
+		failures:    make(map[string][]error),
+		nonces:      make(map[common.Address]uint64),
+		baseNonces:  make(map[common.Address]uint64),
+		usedNonces:  make(map[common.Address]map[uint64]bool),
+		txs:         make(map[common.Hash]*paymasterclient.TransactionResponse),
+		txGas:       make(map[common.Hash]uint64),
+		bundles:     make(map[uuid.UUID]*bundleState),
+		sponsorTxs:  make(map[common.Hash]*bundleState),
+		scripts:     make(map[common.Hash][]paymasterclient.Status),
+		blockNumber: 1,
+		blocks:      make(map[int64][]*types.Transaction),
+		whitelists:  make(map[uuid.UUID]map[sponsorclient.WhitelistType][]string),
+		userSpend:   make(map[uuid.UUID]map[common.Address]*sponsorclient.UserSpendData),
+		policySpend: make(map[uuid.UUID]*sponsorclient.PolicySpendData),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.ChainID == 0 {
+		s.opts.ChainID = defaultChainID
+	}
+	if s.opts.SponsorGasPrice == nil {
+		s.opts.SponsorGasPrice = defaultSponsorGasPrice
+	}
+	if s.opts.BundleSize <= 0 {
+		s.opts.BundleSize = 1
+	}
+
+	s.rpcServer = rpc.NewServer()
+	if err := s.rpcServer.RegisterName("eth", &ethAPI{s}); err != nil {
+		panic(err)
+	}
+	if err := s.rpcServer.RegisterName("pm", &pmAPI{s}); err != nil {
+		panic(err)
+	}
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close shuts down the server. Clients using it get connection errors afterwards.
+func (s *Server) Close() {
+	s.httpServer.Close()
+	s.rpcServer.Stop()
+}
+
+// ChainID returns the chain ID served.
+func (s *Server) ChainID() *big.Int {
+	return big.NewInt(s.opts.ChainID)
+}
+
+type headerKey struct{}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Methods: methodsOf(body), Header: r.Header.Clone(), Path: r.URL.Path})
+	status := s.httpStatus
+	s.mu.Unlock()
+
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	s.rpcServer.ServeHTTP(w, r.WithContext(contextWithHeader(r)))
+}
+
+func methodsOf(body []byte) []string {
+	type call struct {
+		Method string `json:"method"`
+	}
+	var batch []call
+	if err := json.Unmarshal(body, &batch); err != nil {
+		var single call
+		if json.Unmarshal(body, &single) != nil {
+			return nil
+		}
+		batch = []call{single}
+	}
+	methods := make([]string, len(batch))
+	for i, c := range batch {
+		methods[i] = c.Method
+	}
+	return methods
+}
+
+// SetHTTPStatus makes every request fail with the given HTTP status. Zero serves requests again.
+func (s *Server) SetHTTPStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.httpStatus = status
+}
+
+// FailNext makes the next call of method fail with err. Calls queue up, so calling FailNext twice
+// fails the next two calls. Use *Error to control the JSON-RPC error code and data.
+func (s *Server) FailNext(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], err)
+}
+
+// SetSponsorable replaces the sponsorability rule. Nil restores the default rule, which sponsors
+// transactions whose sender, recipient and method selector pass the whitelists of the policy.
+func (s *Server) SetSponsorable(fn SponsorableFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sponsorable = fn
+}
+
+// SetNonce sets the transaction count of address. Transactions with a lower nonce are rejected as nonce too low.
+func (s *Server) SetNonce(address common.Address, nonce uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nonces[address] = nonce
+	s.baseNonces[address] = nonce
+}
+
+// SetStaleNonces makes eth_getTransactionCount ignore the transactions accepted from now on, like
+// transactions waiting in bundles that are not reflected yet.
+func (s *Server) SetStaleNonces(stale bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.staleNonces = stale
+}
+
+// Requests returns the HTTP requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Transactions returns the transactions accepted by eth_sendRawTransaction, in order.
+func (s *Server) Transactions() []*types.Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*types.Transaction(nil), s.sent...)
+}
+
+// popFailure returns the scripted failure of the next call of method, if any. It must be called with s.mu held.
+func (s *Server) popFailure(method string) error {
+	queue := s.failures[method]
+	if len(queue) == 0 {
+		return nil
+	}
+	s.failures[method] = queue[1:]
+	return queue[0]
+}
+
+// Error is a JSON-RPC error returned by the server.
+type Error struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (e *Error) Error() string          { return e.Message }
+func (e *Error) ErrorCode() int         { return e.Code }
+func (e *Error) ErrorData() interface{} { return e.Data }
@@ -0,0 +1,205 @@
+package megafueltest
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+	mftypes "github.com/node-real/megafuel-go-sdk/pkg/types"
+)
+
+func validWhitelistType(t sponsorclient.WhitelistType) error {
+	switch t {
+	case sponsorclient.FromAccountWhitelist, sponsorclient.ToAccountWhitelist,
+		sponsorclient.ContractMethodSigWhitelist, sponsorclient.BEP20ReceiverWhiteList:
+		return nil
+	}
+	return &Error{Code: -32602, Message: fmt.Sprintf("invalid whitelist type %q", t)}
+}
+
+// checkWhitelistArgs checks the arguments of a whitelist update. It must be called with s.mu held.
+func (s *Server) checkWhitelistArgs(args sponsorclient.WhiteListArgs) error {
+	if err := validWhitelistType(args.WhitelistType); err != nil {
+		return err
+	}
+	if s.opts.WhitelistBatchSize > 0 && len(args.Values) > s.opts.WhitelistBatchSize {
+		return &Error{Code: -32602, Message: fmt.Sprintf("too many values: %d, max %d", len(args.Values), s.opts.WhitelistBatchSize)}
+	}
+	return nil
+}
+
+func (api *pmAPI) AddToWhitelist(args sponsorclient.WhiteListArgs) (bool, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_addToWhitelist"); err != nil {
+		return false, err
+	}
+	if err := s.checkWhitelistArgs(args); err != nil {
+		return false, err
+	}
+
+	lists := s.whitelists[args.PolicyUUID]
+	if lists == nil {
+		lists = make(map[sponsorclient.WhitelistType][]string)
+		s.whitelists[args.PolicyUUID] = lists
+	}
+	for _, value := range args.Values {
+		if indexFold(lists[args.WhitelistType], value) < 0 {
+			lists[args.WhitelistType] = append(lists[args.WhitelistType], value)
+		}
+	}
+	return true, nil
+}
+
+func (api *pmAPI) RmFromWhitelist(args sponsorclient.WhiteListArgs) (bool, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_rmFromWhitelist"); err != nil {
+		return false, err
+	}
+	if err := s.checkWhitelistArgs(args); err != nil {
+		return false, err
+	}
+
+	lists := s.whitelists[args.PolicyUUID]
+	for _, value := range args.Values {
+		if i := indexFold(lists[args.WhitelistType], value); i >= 0 {
+			lists[args.WhitelistType] = append(lists[args.WhitelistType][:i], lists[args.WhitelistType][i+1:]...)
+		}
+	}
+	return true, nil
+}
+
+func (api *pmAPI) EmptyWhitelist(args sponsorclient.EmptyWhiteListArgs) (bool, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_emptyWhitelist"); err != nil {
+		return false, err
+	}
+	if err := validWhitelistType(args.WhitelistType); err != nil {
+		return false, err
+	}
+	delete(s.whitelists[args.PolicyUUID], args.WhitelistType)
+	return true, nil
+}
+
+// GetWhitelist returns the values of the whitelist from Offset on. A zero Limit returns all of them.
+func (api *pmAPI) GetWhitelist(args sponsorclient.GetWhitelistArgs) ([]string, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_getWhitelist"); err != nil {
+		return nil, err
+	}
+	if err := validWhitelistType(args.WhitelistType); err != nil {
+		return nil, err
+	}
+	if args.Offset < 0 || args.Limit < 0 {
+		return nil, &Error{Code: -32602, Message: "offset and limit must not be negative"}
+	}
+	if args.Offset >= sponsorclient.MaxOffset || args.Limit >= sponsorclient.MaxOffset {
+		return nil, &Error{Code: -32602, Message: fmt.Sprintf("offset and limit must be less than %d", sponsorclient.MaxOffset)}
+	}
+
+	values := s.whitelists[args.PolicyUUID][args.WhitelistType]
+	page := []string{}
+	if args.Offset < len(values) {
+		end := len(values)
+		if args.Limit > 0 {
+			end = min(end, args.Offset+args.Limit)
+		}
+		page = append(page, values[args.Offset:end]...)
+	}
+	return page, nil
+}
+
+func (api *pmAPI) GetUserSpendData(fromAddress common.Address, policyUUID uuid.UUID) (*sponsorclient.UserSpendData, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_getUserSpendData"); err != nil {
+		return nil, err
+	}
+	user, ok := s.userSpend[policyUUID][fromAddress]
+	if !ok {
+		return nil, fmt.Errorf("spend data of user %s not found", fromAddress)
+	}
+	return copyUserSpend(user), nil
+}
+
+func (api *pmAPI) GetPolicySpendData(policyUUID uuid.UUID) (*sponsorclient.PolicySpendData, error) {
+	s := api.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.popFailure("pm_getPolicySpendData"); err != nil {
+		return nil, err
+	}
+	policy, ok := s.policySpend[policyUUID]
+	if !ok {
+		return nil, fmt.Errorf("spend data of policy %s not found", policyUUID)
+	}
+	return copyPolicySpend(policy), nil
+}
+
+// Whitelist returns the values of a whitelist of a policy.
+func (s *Server) Whitelist(policyUUID uuid.UUID, whitelistType sponsorclient.WhitelistType) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.whitelists[policyUUID][whitelistType]...)
+}
+
+// SetPolicySpendData replaces the spend data of a policy. Accepted transactions keep adding to it.
+func (s *Server) SetPolicySpendData(policyUUID uuid.UUID, data sponsorclient.PolicySpendData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policySpend[policyUUID] = copyPolicySpend(&data)
+}
+
+// SetUserSpendData replaces the spend data of a user on a policy. Accepted transactions keep adding to it.
+func (s *Server) SetUserSpendData(policyUUID uuid.UUID, data sponsorclient.UserSpendData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := s.userSpend[policyUUID]
+	if users == nil {
+		users = make(map[common.Address]*sponsorclient.UserSpendData)
+		s.userSpend[policyUUID] = users
+	}
+	users[data.UserAddress] = copyUserSpend(&data)
+}
+
+func indexFold(values []string, value string) int {
+	for i, v := range values {
+		if strings.EqualFold(v, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyBig returns a copy of b, or zero if b is nil.
+func copyBig(b *mftypes.Big) *mftypes.Big {
+	if b == nil {
+		return (*mftypes.Big)(new(big.Int))
+	}
+	return (*mftypes.Big)(new(big.Int).Set(b.Raw()))
+}
+
+func copyUserSpend(user *sponsorclient.UserSpendData) *sponsorclient.UserSpendData {
+	c := *user
+	c.GasCost = copyBig(user.GasCost)
+	c.GasCostCurDay = copyBig(user.GasCostCurDay)
+	return &c
+}
+
+func copyPolicySpend(policy *sponsorclient.PolicySpendData) *sponsorclient.PolicySpendData {
+	c := *policy
+	c.Cost = copyBig(policy.Cost)
+	return &c
+}
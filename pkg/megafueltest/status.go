@@ -0,0 +1,75 @@
+package megafueltest
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	mftypes "github.com/node-real/megafuel-go-sdk/pkg/types"
+)
+
+// SetStatus moves the transaction txHash to status. Its bundle and sponsor transaction follow, so
+// all transactions of a bundle end up with the status of the last one set. Confirming a transaction
+// mines a new block and fills in the gas accounting of the bundle.
+func (s *Server) SetStatus(txHash common.Hash, status paymasterclient.Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.txs[txHash]; !ok {
+		return fmt.Errorf("transaction %s not found", txHash)
+	}
+	s.setStatus(txHash, status)
+	return nil
+}
+
+// ScriptStatuses makes the next eth_getGaslessTransactionByHash calls for txHash move the transaction
+// through statuses, one per call, as if it progressed between polls. The last status sticks.
+func (s *Server) ScriptStatuses(txHash common.Hash, statuses ...paymasterclient.Status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[txHash] = append(s.scripts[txHash], statuses...)
+}
+
+// ConfirmAll confirms every transaction that is not in a terminal status yet.
+func (s *Server) ConfirmAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tx := range s.sent {
+		if !s.txs[tx.Hash()].Status.IsTerminal() {
+			s.setStatus(tx.Hash(), paymasterclient.StatusConfirmed)
+		}
+	}
+}
+
+// setStatus must be called with s.mu held.
+func (s *Server) setStatus(txHash common.Hash, status paymasterclient.Status) {
+	tx := s.txs[txHash]
+	b := s.bundles[tx.BundleUUID]
+
+	if status == paymasterclient.StatusConfirmed && b.bundle.Status != paymasterclient.StatusConfirmed {
+		s.blockNumber++
+		b.bundle.ConfirmedBlockNumber = s.blockNumber
+		b.bundle.ConfirmedDate = uint64(time.Now().Unix())
+		s.blocks[s.blockNumber] = append([]*types.Transaction{b.sponsorRawTx}, b.txs...)
+
+		// The sponsor pays for the user transactions and its own transfer.
+		var gasUsed uint64 = 21000
+		for _, h := range b.txHashes {
+			gasUsed += s.txGas[h]
+			s.txs[h].GasUsed = s.txGas[h]
+		}
+		b.sponsorTx.GasFee = (*mftypes.Big)(new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), s.opts.SponsorGasPrice))
+	}
+
+	tx.Status = status
+	b.bundle.Status = status
+	b.sponsorTx.Status = status
+	if s.openBundle == b && status != paymasterclient.StatusNew {
+		s.openBundle = nil
+	}
+}
@@ -0,0 +1,263 @@
+// Package mferrors turns the errors returned by the MegaFuel JSON-RPC endpoints into typed errors.
+//
+// Every error returned by paymasterclient and sponsorclient is an *Error carrying the JSON-RPC method,
+// the request ID and whether the call may be retried. Well-known failures can be matched with errors.Is
+// against the sentinel errors of this package, and the underlying go-ethereum error stays reachable
+// with errors.As, e.g. rpc.HTTPError. API keys are redacted from the messages, see apikey.Redact.
+package mferrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/apikey"
+)
+
+// RequestIDHeader is the HTTP header carrying the request ID of a call.
+const RequestIDHeader = "X-Request-Id"
+
+// Standard JSON-RPC error codes.
+const (
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternal       = -32603
+	CodeLimitExceeded  = -32005
+)
+
+var (
+	// ErrNotSponsorable means the paymaster declined to sponsor the transaction.
+	ErrNotSponsorable = errors.New("transaction is not sponsorable")
+	// ErrPolicyExhausted means the sponsor policy has run out of budget or quota.
+	ErrPolicyExhausted = errors.New("policy exhausted")
+	// ErrNonceTooLow means the transaction nonce has already been used.
+	ErrNonceTooLow = errors.New("nonce too low")
+	// ErrNonceTooHigh means the transaction nonce leaves a gap after the account nonce.
+	ErrNonceTooHigh = errors.New("nonce too high")
+	// ErrAlreadyKnown means the transaction has already been accepted.
+	ErrAlreadyKnown = errors.New("already known")
+	// ErrInvalidWhitelistType means the whitelist type is not supported by the policy.
+	ErrInvalidWhitelistType = errors.New("invalid whitelist type")
+	// ErrNotFound means the requested transaction, bundle or policy does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited means the endpoint rejected the call because of rate limiting.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnauthorized means the API key or policy is not allowed to make the call.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrUnavailable means the endpoint could not be reached or failed with a server error.
+	ErrUnavailable = errors.New("service unavailable")
+	// ErrMethodNotFound means the endpoint does not support the method.
+	ErrMethodNotFound = errors.New("method not found")
+	// ErrInvalidParams means the endpoint rejected the parameters of the call.
+	ErrInvalidParams = errors.New("invalid params")
+)
+
+// Error is a failed MegaFuel JSON-RPC call.
+type Error struct {
+	Method     string      // Method is the JSON-RPC method of the call.
+	RequestID  string      // RequestID is the ID sent in the RequestIDHeader of the call, empty if the call was not sent.
+	Code       int         // Code is the JSON-RPC error code, zero if the call did not get a JSON-RPC error.
+	HTTPStatus int         // HTTPStatus is the HTTP status code, zero if the call did not get a non-2xx response.
+	Message    string      // Message is the error message reported by the endpoint or the transport.
+	Data       interface{} // Data is the data attached to the JSON-RPC error, if any.
+	Kind       error       // Kind is the sentinel error the failure was classified as, nil if unknown.
+	Retryable  bool        // Retryable is true if the failure is transient and the call may succeed when retried.
+	Err        error       // Err is the underlying error.
+}
+
+func (e *Error) Error() string {
+	if e.RequestID == "" {
+		return fmt.Sprintf("%s: %s", e.Method, e.Message)
+	}
+	return fmt.Sprintf("%s (request %s): %s", e.Method, e.RequestID, e.Message)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error was classified as target.
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// NewRequestID returns a new random request ID.
+func NewRequestID() string {
+	return uuid.Must(uuid.NewV4()).String()
+}
+
+// Wrap classifies err, returned by a call of method with the given request ID, into an *Error.
+// It returns nil if err is nil and err itself if it already is an *Error.
+func Wrap(method, requestID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	// The transport errors of endpoints dialed with the API key in their URL embed the key.
+	e = &Error{Method: method, RequestID: requestID, Message: apikey.Redact(err.Error()), Err: apikey.RedactError(err)}
+
+	var httpErr rpc.HTTPError
+	var rpcErr rpc.Error
+	switch {
+	case errors.As(err, &httpErr):
+		e.HTTPStatus = httpErr.StatusCode
+		e.Kind, e.Retryable = classifyHTTPStatus(httpErr.StatusCode)
+	case errors.As(err, &rpcErr):
+		e.Code = rpcErr.ErrorCode()
+		e.Kind, e.Retryable = classifyCode(e.Code)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return e
+	case isNetworkError(err):
+		e.Kind, e.Retryable = ErrUnavailable, true
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		e.Data = dataErr.ErrorData()
+	}
+
+	// The MegaFuel endpoints report most failures with the generic error code, so fall back
+	// to the message for anything the code and status did not already identify.
+	if e.Kind == nil || e.Kind == ErrInvalidParams {
+		if kind := classifyMessage(e.Message, e.Data); kind != nil {
+			e.Kind = kind
+			e.Retryable = kind == ErrRateLimited
+		}
+	}
+	return e
+}
+
+// IsRetryable reports whether err is a transient failure that may succeed when retried.
+func IsRetryable(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Retryable
+	}
+	return isNetworkError(err)
+}
+
+// kindClasses maps the sentinel errors to their Class.
+var kindClasses = map[error]string{
+	ErrNotSponsorable:       "not_sponsorable",
+	ErrPolicyExhausted:      "policy_exhausted",
+	ErrNonceTooLow:          "nonce_too_low",
+	ErrNonceTooHigh:         "nonce_too_high",
+	ErrAlreadyKnown:         "already_known",
+	ErrInvalidWhitelistType: "invalid_whitelist_type",
+	ErrNotFound:             "not_found",
+	ErrRateLimited:          "rate_limited",
+	ErrUnauthorized:         "unauthorized",
+	ErrUnavailable:          "unavailable",
+	ErrMethodNotFound:       "method_not_found",
+	ErrInvalidParams:        "invalid_params",
+}
+
+// Class returns a short snake_case name of the class of err, suitable as a metric label: "ok" for nil,
+// the name of its sentinel error such as "nonce_too_low", "canceled" or "timeout" for context errors,
+// and "unknown" otherwise.
+func Class(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	var e *Error
+	if errors.As(err, &e) && e.Kind != nil {
+		if class, ok := kindClasses[e.Kind]; ok {
+			return class
+		}
+	}
+	for kind, class := range kindClasses {
+		if errors.Is(err, kind) {
+			return class
+		}
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "unknown"
+}
+
+func classifyHTTPStatus(status int) (kind error, retryable bool) {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited, true
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ErrUnauthorized, false
+	case status >= 500:
+		return ErrUnavailable, status != http.StatusNotImplemented
+	}
+	return nil, false
+}
+
+func classifyCode(code int) (kind error, retryable bool) {
+	switch code {
+	case CodeMethodNotFound:
+		return ErrMethodNotFound, false
+	case CodeInvalidParams:
+		return ErrInvalidParams, false
+	case CodeLimitExceeded:
+		return ErrRateLimited, true
+	}
+	return nil, false
+}
+
+// messageKinds maps lower-cased message fragments to the sentinel errors they indicate.
+var messageKinds = []struct {
+	fragment string
+	kind     error
+}{
+	{"not sponsorable", ErrNotSponsorable},
+	{"unsponsorable", ErrNotSponsorable},
+	{"policy exhausted", ErrPolicyExhausted},
+	{"exceeds policy", ErrPolicyExhausted},
+	{"exceed the limit", ErrPolicyExhausted},
+	{"insufficient policy", ErrPolicyExhausted},
+	{"nonce too low", ErrNonceTooLow},
+	{"nonce too high", ErrNonceTooHigh},
+	{"nonce gap", ErrNonceTooHigh},
+	{"already known", ErrAlreadyKnown},
+	{"invalid whitelist type", ErrInvalidWhitelistType},
+	{"rate limit", ErrRateLimited},
+	{"too many requests", ErrRateLimited},
+	{"unauthorized", ErrUnauthorized},
+	{"invalid api key", ErrUnauthorized},
+	{"not found", ErrNotFound},
+}
+
+func classifyMessage(message string, data interface{}) error {
+	text := strings.ToLower(message)
+	if s, ok := data.(string); ok {
+		text += " " + strings.ToLower(s)
+	}
+	for _, mk := range messageKinds {
+		if strings.Contains(text, mk.fragment) {
+			return mk.kind
+		}
+	}
+	return nil
+}
+
+func isNetworkError(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,160 @@
+// Package mfmetrics instruments paymasterclient.Client and sponsorclient.Client with Prometheus metrics.
+//
+// The collectors are registered with the prometheus.Registerer given to New, never globally:
+//
+//	megafuel_client_requests_total{client,method,class}        calls by JSON-RPC method and mferrors.Class
+//	megafuel_client_request_duration_seconds{client,method}    call latency
+//	megafuel_sponsorable_checks_total{sponsorable}             IsSponsorable answers, "true" or "false"
+//	megafuel_transactions_final_status_total{status}           transactions sent by final Status
+//
+// Each item of a batch call is counted as a call, the latency of a batch call is observed once.
+package mfmetrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+const (
+	namespace = "megafuel"
+
+	clientPaymaster = "paymaster"
+	clientSponsor   = "sponsor"
+
+	// maxPendingSends bounds the number of sent transactions waiting for their final status.
+	maxPendingSends = 10000
+)
+
+// Options defines the options for New.
+type Options struct {
+	// Buckets are the buckets of the latency histogram. Default value is prometheus.DefBuckets.
+	Buckets []float64
+	// ConstLabels are added to every metric, e.g. to tell several clients apart.
+	ConstLabels prometheus.Labels
+}
+
+// Metrics holds the collectors shared by the clients it instruments.
+type Metrics struct {
+	requests    *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	sponsorable *prometheus.CounterVec
+	finalStatus *prometheus.CounterVec
+
+	mu           sync.Mutex
+	pending      map[common.Hash]struct{}
+	pendingOrder []common.Hash
+}
+
+// New creates the collectors and registers them with reg. It fails if reg already has them, e.g. when
+// New is called twice with the same registerer.
+func New(reg prometheus.Registerer, opts *Options) (*Metrics, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Buckets == nil {
+		o.Buckets = prometheus.DefBuckets
+	}
+
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "client",
+			Name:        "requests_total",
+			Help:        "MegaFuel JSON-RPC calls by method and error class.",
+			ConstLabels: o.ConstLabels,
+		}, []string{"client", "method", "class"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   "client",
+			Name:        "request_duration_seconds",
+			Help:        "Latency of MegaFuel JSON-RPC calls by method.",
+			Buckets:     o.Buckets,
+			ConstLabels: o.ConstLabels,
+		}, []string{"client", "method"}),
+		sponsorable: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "sponsorable_checks_total",
+			Help:        "Transactions checked with IsSponsorable by answer.",
+			ConstLabels: o.ConstLabels,
+		}, []string{"sponsorable"}),
+		finalStatus: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "transactions_final_status_total",
+			Help:        "Transactions sent through the client by the final status first observed through it.",
+			ConstLabels: o.ConstLabels,
+		}, []string{"status"}),
+		pending: make(map[common.Hash]struct{}),
+	}
+	for _, c := range []prometheus.Collector{m.requests, m.duration, m.sponsorable, m.finalStatus} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// observe calls call and records its latency and error class as a call of method.
+func observe[T any](m *Metrics, client, method string, call func() (T, error)) (T, error) {
+	start := time.Now()
+	result, err := call()
+	m.duration.WithLabelValues(client, method).Observe(time.Since(start).Seconds())
+	m.requests.WithLabelValues(client, method, mferrors.Class(err)).Inc()
+	return result, err
+}
+
+// observeBatch calls call and records its latency once and the error class of every item as a call of method.
+func observeBatch[T any](m *Metrics, method string, call func() ([]paymasterclient.BatchResult[T], error)) ([]paymasterclient.BatchResult[T], error) {
+	start := time.Now()
+	results, err := call()
+	m.duration.WithLabelValues(clientPaymaster, method).Observe(time.Since(start).Seconds())
+	if results == nil && err != nil {
+		m.requests.WithLabelValues(clientPaymaster, method, mferrors.Class(err)).Inc()
+	}
+	for _, result := range results {
+		m.requests.WithLabelValues(clientPaymaster, method, mferrors.Class(result.Err)).Inc()
+	}
+	return results, err
+}
+
+func (m *Metrics) observeSponsorable(resp *paymasterclient.IsSponsorableResponse) {
+	if resp != nil {
+		m.sponsorable.WithLabelValues(strconv.FormatBool(resp.Sponsorable)).Inc()
+	}
+}
+
+// sent remembers txHash until its final status is observed.
+func (m *Metrics) sent(txHash common.Hash) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pending[txHash]; ok {
+		return
+	}
+	if len(m.pendingOrder) >= maxPendingSends {
+		delete(m.pending, m.pendingOrder[0])
+		m.pendingOrder = m.pendingOrder[1:]
+	}
+	m.pending[txHash] = struct{}{}
+	m.pendingOrder = append(m.pendingOrder, txHash)
+}
+
+// observeStatus counts the final status of tx the first time it is observed, if tx was sent through the client.
+func (m *Metrics) observeStatus(tx *paymasterclient.TransactionResponse) {
+	if tx == nil || !tx.Status.IsTerminal() {
+		return
+	}
+	m.mu.Lock()
+	_, ok := m.pending[tx.TxHash]
+	delete(m.pending, tx.TxHash)
+	m.mu.Unlock()
+	if ok {
+		m.finalStatus.WithLabelValues(tx.Status.String()).Inc()
+	}
+}
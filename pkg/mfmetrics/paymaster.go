@@ -0,0 +1,115 @@
+package mfmetrics
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+type paymasterClient struct {
+	c paymasterclient.Client
+	m *Metrics
+}
+
+// Paymaster wraps c so that its calls are recorded in m. The final status of a transaction sent through
+// the returned client is counted when GetGaslessTransactionByHash or BatchGetGaslessTransactions, e.g.
+// through paymasterclient.WaitForGaslessTransaction, first returns it with a terminal status.
+func (m *Metrics) Paymaster(c paymasterclient.Client) paymasterclient.Client {
+	return &paymasterClient{c, m}
+}
+
+func (p *paymasterClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return observe(p.m, clientPaymaster, "eth_chainId", func() (*big.Int, error) {
+		return p.c.ChainID(ctx)
+	})
+}
+
+func (p *paymasterClient) IsSponsorable(ctx context.Context, tx paymasterclient.TransactionArgs) (*paymasterclient.IsSponsorableResponse, error) {
+	resp, err := observe(p.m, clientPaymaster, "pm_isSponsorable", func() (*paymasterclient.IsSponsorableResponse, error) {
+		return p.c.IsSponsorable(ctx, tx)
+	})
+	if err == nil {
+		p.m.observeSponsorable(resp)
+	}
+	return resp, err
+}
+
+func (p *paymasterClient) SendRawTransaction(ctx context.Context, input hexutil.Bytes, opts *paymasterclient.TransactionOptions) (common.Hash, error) {
+	hash, err := observe(p.m, clientPaymaster, "eth_sendRawTransaction", func() (common.Hash, error) {
+		return p.c.SendRawTransaction(ctx, input, opts)
+	})
+	if err == nil {
+		p.m.sent(hash)
+	}
+	return hash, err
+}
+
+func (p *paymasterClient) GetGaslessTransactionByHash(ctx context.Context, txHash common.Hash) (*paymasterclient.TransactionResponse, error) {
+	tx, err := observe(p.m, clientPaymaster, "eth_getGaslessTransactionByHash", func() (*paymasterclient.TransactionResponse, error) {
+		return p.c.GetGaslessTransactionByHash(ctx, txHash)
+	})
+	if err == nil {
+		p.m.observeStatus(tx)
+	}
+	return tx, err
+}
+
+func (p *paymasterClient) GetSponsorTxByTxHash(ctx context.Context, txHash common.Hash) (*paymasterclient.SponsorTx, error) {
+	return observe(p.m, clientPaymaster, "pm_getSponsorTxByTxHash", func() (*paymasterclient.SponsorTx, error) {
+		return p.c.GetSponsorTxByTxHash(ctx, txHash)
+	})
+}
+
+func (p *paymasterClient) GetSponsorTxByBundleUUID(ctx context.Context, bundleUUID uuid.UUID) (*paymasterclient.SponsorTx, error) {
+	return observe(p.m, clientPaymaster, "pm_getSponsorTxByBundleUuid", func() (*paymasterclient.SponsorTx, error) {
+		return p.c.GetSponsorTxByBundleUUID(ctx, bundleUUID)
+	})
+}
+
+func (p *paymasterClient) GetBundleByUUID(ctx context.Context, bundleUUID uuid.UUID) (*paymasterclient.Bundle, error) {
+	return observe(p.m, clientPaymaster, "pm_getBundleByUuid", func() (*paymasterclient.Bundle, error) {
+		return p.c.GetBundleByUUID(ctx, bundleUUID)
+	})
+}
+
+func (p *paymasterClient) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	return observe(p.m, clientPaymaster, "eth_getTransactionCount", func() (uint64, error) {
+		return p.c.GetTransactionCount(ctx, address, blockNrOrHash)
+	})
+}
+
+func (p *paymasterClient) BatchIsSponsorable(ctx context.Context, txs []paymasterclient.TransactionArgs) ([]paymasterclient.BatchResult[paymasterclient.IsSponsorableResponse], error) {
+	results, err := observeBatch(p.m, "pm_isSponsorable", func() ([]paymasterclient.BatchResult[paymasterclient.IsSponsorableResponse], error) {
+		return p.c.BatchIsSponsorable(ctx, txs)
+	})
+	for _, result := range results {
+		if result.Err == nil {
+			p.m.observeSponsorable(result.Result)
+		}
+	}
+	return results, err
+}
+
+func (p *paymasterClient) BatchGetGaslessTransactions(ctx context.Context, txHashes []common.Hash) ([]paymasterclient.BatchResult[paymasterclient.TransactionResponse], error) {
+	results, err := observeBatch(p.m, "eth_getGaslessTransactionByHash", func() ([]paymasterclient.BatchResult[paymasterclient.TransactionResponse], error) {
+		return p.c.BatchGetGaslessTransactions(ctx, txHashes)
+	})
+	for _, result := range results {
+		if result.Err == nil {
+			p.m.observeStatus(result.Result)
+		}
+	}
+	return results, err
+}
+
+func (p *paymasterClient) BatchGetSponsorTxByTxHash(ctx context.Context, txHashes []common.Hash) ([]paymasterclient.BatchResult[paymasterclient.SponsorTx], error) {
+	return observeBatch(p.m, "pm_getSponsorTxByTxHash", func() ([]paymasterclient.BatchResult[paymasterclient.SponsorTx], error) {
+		return p.c.BatchGetSponsorTxByTxHash(ctx, txHashes)
+	})
+}
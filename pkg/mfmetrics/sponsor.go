@@ -0,0 +1,62 @@
+package mfmetrics
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+type sponsorClient struct {
+	c sponsorclient.Client
+	m *Metrics
+}
+
+// Sponsor wraps c so that its calls are recorded in m.
+func (m *Metrics) Sponsor(c sponsorclient.Client) sponsorclient.Client {
+	return &sponsorClient{c, m}
+}
+
+func (s *sponsorClient) AddToWhitelist(ctx context.Context, args sponsorclient.WhiteListArgs) (bool, error) {
+	return observe(s.m, clientSponsor, "pm_addToWhitelist", func() (bool, error) {
+		return s.c.AddToWhitelist(ctx, args)
+	})
+}
+
+func (s *sponsorClient) RmFromWhitelist(ctx context.Context, args sponsorclient.WhiteListArgs) (bool, error) {
+	return observe(s.m, clientSponsor, "pm_rmFromWhitelist", func() (bool, error) {
+		return s.c.RmFromWhitelist(ctx, args)
+	})
+}
+
+func (s *sponsorClient) EmptyWhitelist(ctx context.Context, args sponsorclient.EmptyWhiteListArgs) (bool, error) {
+	return observe(s.m, clientSponsor, "pm_emptyWhitelist", func() (bool, error) {
+		return s.c.EmptyWhitelist(ctx, args)
+	})
+}
+
+func (s *sponsorClient) GetWhitelist(ctx context.Context, args sponsorclient.GetWhitelistArgs) (interface{}, error) {
+	return observe(s.m, clientSponsor, "pm_getWhitelist", func() (interface{}, error) {
+		return s.c.GetWhitelist(ctx, args)
+	})
+}
+
+func (s *sponsorClient) GetWhitelistPage(ctx context.Context, args sponsorclient.GetWhitelistArgs) (*sponsorclient.WhitelistPage, error) {
+	return observe(s.m, clientSponsor, "pm_getWhitelist", func() (*sponsorclient.WhitelistPage, error) {
+		return s.c.GetWhitelistPage(ctx, args)
+	})
+}
+
+func (s *sponsorClient) GetUserSpendData(ctx context.Context, fromAddress common.Address, policyUUID uuid.UUID) (*sponsorclient.UserSpendData, error) {
+	return observe(s.m, clientSponsor, "pm_getUserSpendData", func() (*sponsorclient.UserSpendData, error) {
+		return s.c.GetUserSpendData(ctx, fromAddress, policyUUID)
+	})
+}
+
+func (s *sponsorClient) GetPolicySpendData(ctx context.Context, policyUUID uuid.UUID) (*sponsorclient.PolicySpendData, error) {
+	return observe(s.m, clientSponsor, "pm_getPolicySpendData", func() (*sponsorclient.PolicySpendData, error) {
+		return s.c.GetPolicySpendData(ctx, policyUUID)
+	})
+}
@@ -0,0 +1,168 @@
+package mftrace
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+type paymasterClient struct {
+	c paymasterclient.Client
+	t *tracer
+}
+
+// NewPaymasterClient wraps c so that every call is traced, see the package documentation.
+func NewPaymasterClient(c paymasterclient.Client, opts *Options) paymasterclient.Client {
+	return &paymasterClient{c, newTracer("paymaster", opts)}
+}
+
+// policyAttrs returns the policy attribute of a call using the policy of ctx.
+func (p *paymasterClient) policyAttrs(ctx context.Context) []attribute.KeyValue {
+	if policyUUID, ok := paymasterclient.PolicyUUIDFromContext(ctx); ok {
+		return []attribute.KeyValue{PolicyUUIDKey.String(policyUUID)}
+	}
+	return uuidAttr(PolicyUUIDKey, p.t.policyUUID)
+}
+
+func (p *paymasterClient) ChainID(ctx context.Context) (*big.Int, error) {
+	ctx, span := p.t.start(ctx, "eth_chainId")
+	chainID, err := p.c.ChainID(ctx)
+	if err == nil && chainID != nil {
+		span.SetAttributes(ChainIDKey.String(chainID.String()))
+		p.t.chainID.CompareAndSwap(nil, new(big.Int).Set(chainID))
+	}
+	end(span, err)
+	return chainID, err
+}
+
+func (p *paymasterClient) IsSponsorable(ctx context.Context, tx paymasterclient.TransactionArgs) (*paymasterclient.IsSponsorableResponse, error) {
+	ctx, span := p.t.start(ctx, "pm_isSponsorable", p.policyAttrs(ctx)...)
+	resp, err := p.c.IsSponsorable(ctx, tx)
+	if err == nil && resp != nil {
+		span.SetAttributes(SponsorableKey.Bool(resp.Sponsorable))
+	}
+	end(span, err)
+	return resp, err
+}
+
+func (p *paymasterClient) SendRawTransaction(ctx context.Context, input hexutil.Bytes, opts *paymasterclient.TransactionOptions) (common.Hash, error) {
+	attrs := p.policyAttrs(ctx)
+	// The hash is known before the call, so that failed sends can be matched with the transaction.
+	tx := new(types.Transaction)
+	if tx.UnmarshalBinary(input) == nil {
+		attrs = append(attrs, TxHashKey.String(tx.Hash().Hex()))
+	}
+	ctx, span := p.t.start(ctx, "eth_sendRawTransaction", attrs...)
+	hash, err := p.c.SendRawTransaction(ctx, input, opts)
+	if err == nil {
+		span.SetAttributes(TxHashKey.String(hash.Hex()))
+	}
+	end(span, err)
+	return hash, err
+}
+
+func (p *paymasterClient) GetGaslessTransactionByHash(ctx context.Context, txHash common.Hash) (*paymasterclient.TransactionResponse, error) {
+	ctx, span := p.t.start(ctx, "eth_getGaslessTransactionByHash", TxHashKey.String(txHash.Hex()))
+	tx, err := p.c.GetGaslessTransactionByHash(ctx, txHash)
+	if err == nil && tx != nil {
+		setTransactionAttrs(span, tx)
+	}
+	end(span, err)
+	return tx, err
+}
+
+func setTransactionAttrs(span trace.Span, tx *paymasterclient.TransactionResponse) {
+	span.SetAttributes(StatusKey.String(tx.Status.String()))
+	span.SetAttributes(uuidAttr(PolicyUUIDKey, tx.PolicyUUID)...)
+	span.SetAttributes(uuidAttr(BundleUUIDKey, tx.BundleUUID)...)
+}
+
+func (p *paymasterClient) GetSponsorTxByTxHash(ctx context.Context, txHash common.Hash) (*paymasterclient.SponsorTx, error) {
+	ctx, span := p.t.start(ctx, "pm_getSponsorTxByTxHash", TxHashKey.String(txHash.Hex()))
+	sponsorTx, err := p.c.GetSponsorTxByTxHash(ctx, txHash)
+	if err == nil && sponsorTx != nil {
+		span.SetAttributes(StatusKey.String(sponsorTx.Status.String()))
+		span.SetAttributes(uuidAttr(BundleUUIDKey, sponsorTx.BundleUUID)...)
+	}
+	end(span, err)
+	return sponsorTx, err
+}
+
+func (p *paymasterClient) GetSponsorTxByBundleUUID(ctx context.Context, bundleUUID uuid.UUID) (*paymasterclient.SponsorTx, error) {
+	ctx, span := p.t.start(ctx, "pm_getSponsorTxByBundleUuid", BundleUUIDKey.String(bundleUUID.String()))
+	sponsorTx, err := p.c.GetSponsorTxByBundleUUID(ctx, bundleUUID)
+	if err == nil && sponsorTx != nil {
+		span.SetAttributes(StatusKey.String(sponsorTx.Status.String()))
+	}
+	end(span, err)
+	return sponsorTx, err
+}
+
+func (p *paymasterClient) GetBundleByUUID(ctx context.Context, bundleUUID uuid.UUID) (*paymasterclient.Bundle, error) {
+	ctx, span := p.t.start(ctx, "pm_getBundleByUuid", BundleUUIDKey.String(bundleUUID.String()))
+	bundle, err := p.c.GetBundleByUUID(ctx, bundleUUID)
+	if err == nil && bundle != nil {
+		span.SetAttributes(StatusKey.String(bundle.Status.String()))
+	}
+	end(span, err)
+	return bundle, err
+}
+
+func (p *paymasterClient) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	ctx, span := p.t.start(ctx, "eth_getTransactionCount")
+	nonce, err := p.c.GetTransactionCount(ctx, address, blockNrOrHash)
+	end(span, err)
+	return nonce, err
+}
+
+// A batch call is traced as a single span; the failures of its items are recorded with their index.
+
+func (p *paymasterClient) BatchIsSponsorable(ctx context.Context, txs []paymasterclient.TransactionArgs) ([]paymasterclient.BatchResult[paymasterclient.IsSponsorableResponse], error) {
+	attrs := append(p.policyAttrs(ctx), BatchSizeKey.Int(len(txs)))
+	ctx, span := p.t.start(ctx, "pm_isSponsorable", attrs...)
+	results, err := p.c.BatchIsSponsorable(ctx, txs)
+	recordBatchErrors(span, results)
+	end(span, err)
+	return results, err
+}
+
+func (p *paymasterClient) BatchGetGaslessTransactions(ctx context.Context, txHashes []common.Hash) ([]paymasterclient.BatchResult[paymasterclient.TransactionResponse], error) {
+	ctx, span := p.t.start(ctx, "eth_getGaslessTransactionByHash", BatchSizeKey.Int(len(txHashes)), TxHashKey.StringSlice(hexes(txHashes)))
+	results, err := p.c.BatchGetGaslessTransactions(ctx, txHashes)
+	recordBatchErrors(span, results)
+	end(span, err)
+	return results, err
+}
+
+func (p *paymasterClient) BatchGetSponsorTxByTxHash(ctx context.Context, txHashes []common.Hash) ([]paymasterclient.BatchResult[paymasterclient.SponsorTx], error) {
+	ctx, span := p.t.start(ctx, "pm_getSponsorTxByTxHash", BatchSizeKey.Int(len(txHashes)), TxHashKey.StringSlice(hexes(txHashes)))
+	results, err := p.c.BatchGetSponsorTxByTxHash(ctx, txHashes)
+	recordBatchErrors(span, results)
+	end(span, err)
+	return results, err
+}
+
+func recordBatchErrors[T any](span trace.Span, results []paymasterclient.BatchResult[T]) {
+	for i, result := range results {
+		if result.Err != nil {
+			recordError(span, result.Err, BatchIndexKey.Int(i))
+		}
+	}
+}
+
+func hexes(hashes []common.Hash) []string {
+	s := make([]string, len(hashes))
+	for i, hash := range hashes {
+		s[i] = hash.Hex()
+	}
+	return s
+}
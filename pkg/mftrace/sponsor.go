@@ -0,0 +1,69 @@
+package mftrace
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+type sponsorClient struct {
+	c sponsorclient.Client
+	t *tracer
+}
+
+// NewSponsorClient wraps c so that every call is traced, see the package documentation.
+func NewSponsorClient(c sponsorclient.Client, opts *Options) sponsorclient.Client {
+	return &sponsorClient{c, newTracer("sponsor", opts)}
+}
+
+func (s *sponsorClient) AddToWhitelist(ctx context.Context, args sponsorclient.WhiteListArgs) (bool, error) {
+	ctx, span := s.t.start(ctx, "pm_addToWhitelist", PolicyUUIDKey.String(args.PolicyUUID.String()), BatchSizeKey.Int(len(args.Values)))
+	ok, err := s.c.AddToWhitelist(ctx, args)
+	end(span, err)
+	return ok, err
+}
+
+func (s *sponsorClient) RmFromWhitelist(ctx context.Context, args sponsorclient.WhiteListArgs) (bool, error) {
+	ctx, span := s.t.start(ctx, "pm_rmFromWhitelist", PolicyUUIDKey.String(args.PolicyUUID.String()), BatchSizeKey.Int(len(args.Values)))
+	ok, err := s.c.RmFromWhitelist(ctx, args)
+	end(span, err)
+	return ok, err
+}
+
+func (s *sponsorClient) EmptyWhitelist(ctx context.Context, args sponsorclient.EmptyWhiteListArgs) (bool, error) {
+	ctx, span := s.t.start(ctx, "pm_emptyWhitelist", PolicyUUIDKey.String(args.PolicyUUID.String()))
+	ok, err := s.c.EmptyWhitelist(ctx, args)
+	end(span, err)
+	return ok, err
+}
+
+func (s *sponsorClient) GetWhitelist(ctx context.Context, args sponsorclient.GetWhitelistArgs) (interface{}, error) {
+	ctx, span := s.t.start(ctx, "pm_getWhitelist", PolicyUUIDKey.String(args.PolicyUUID.String()))
+	result, err := s.c.GetWhitelist(ctx, args)
+	end(span, err)
+	return result, err
+}
+
+func (s *sponsorClient) GetWhitelistPage(ctx context.Context, args sponsorclient.GetWhitelistArgs) (*sponsorclient.WhitelistPage, error) {
+	ctx, span := s.t.start(ctx, "pm_getWhitelist", PolicyUUIDKey.String(args.PolicyUUID.String()))
+	page, err := s.c.GetWhitelistPage(ctx, args)
+	end(span, err)
+	return page, err
+}
+
+func (s *sponsorClient) GetUserSpendData(ctx context.Context, fromAddress common.Address, policyUUID uuid.UUID) (*sponsorclient.UserSpendData, error) {
+	ctx, span := s.t.start(ctx, "pm_getUserSpendData", PolicyUUIDKey.String(policyUUID.String()))
+	data, err := s.c.GetUserSpendData(ctx, fromAddress, policyUUID)
+	end(span, err)
+	return data, err
+}
+
+func (s *sponsorClient) GetPolicySpendData(ctx context.Context, policyUUID uuid.UUID) (*sponsorclient.PolicySpendData, error) {
+	ctx, span := s.t.start(ctx, "pm_getPolicySpendData", PolicyUUIDKey.String(policyUUID.String()))
+	data, err := s.c.GetPolicySpendData(ctx, policyUUID)
+	end(span, err)
+	return data, err
+}
@@ -0,0 +1,135 @@
+// Package mftrace instruments paymasterclient.Client and sponsorclient.Client with OpenTelemetry tracing.
+//
+// Every call runs in a client span named after its JSON-RPC method, with the rpc.* semantic convention
+// attributes and the MegaFuel attributes below when they are known: from the arguments before the call,
+// from the result after it. Failures are recorded on the span, which gets an error status. The trace
+// context of the span is sent to the endpoint in the HTTP headers of the call, with the propagator of
+// the Options.
+package mftrace
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+)
+
+// ScopeName is the instrumentation scope name of the tracer.
+const ScopeName = "github.com/node-real/megafuel-go-sdk/pkg/mftrace"
+
+// The MegaFuel span attributes.
+const (
+	ChainIDKey     = attribute.Key("megafuel.chain_id")
+	PolicyUUIDKey  = attribute.Key("megafuel.policy_uuid")
+	TxHashKey      = attribute.Key("megafuel.tx_hash") // TxHashKey holds a string slice on batch spans.
+	BundleUUIDKey  = attribute.Key("megafuel.bundle_uuid")
+	StatusKey      = attribute.Key("megafuel.status") // StatusKey is the status of the transaction or bundle read.
+	SponsorableKey = attribute.Key("megafuel.sponsorable")
+	BatchSizeKey   = attribute.Key("megafuel.batch_size")
+	BatchIndexKey  = attribute.Key("megafuel.batch_index") // BatchIndexKey is the item of a failure recorded on a batch span.
+	RequestIDKey   = attribute.Key("megafuel.request_id")  // RequestIDKey is the mferrors.RequestIDHeader of a failed call.
+	ErrorClassKey  = attribute.Key("megafuel.error_class") // ErrorClassKey is the mferrors.Class of a failure.
+)
+
+// Options defines the options for NewPaymasterClient and NewSponsorClient.
+type Options struct {
+	// TracerProvider creates the tracer. Default value is otel.GetTracerProvider().
+	TracerProvider trace.TracerProvider
+	// Propagator injects the trace context into the HTTP headers of the calls. Default value is
+	// otel.GetTextMapPropagator().
+	Propagator propagation.TextMapPropagator
+	// ChainID is recorded on every span. If nil, the paymaster client records the chain ID once a ChainID
+	// call returned it.
+	ChainID *big.Int
+	// PolicyUUID is recorded on the paymaster spans whose context has no policy, see
+	// paymasterclient.WithPolicyUUID; set it to the private policy of the client, if any.
+	PolicyUUID uuid.UUID
+}
+
+type tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	service    string
+	policyUUID uuid.UUID
+	chainID    atomic.Pointer[big.Int]
+}
+
+func newTracer(service string, opts *Options) *tracer {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.TracerProvider == nil {
+		o.TracerProvider = otel.GetTracerProvider()
+	}
+	if o.Propagator == nil {
+		o.Propagator = otel.GetTextMapPropagator()
+	}
+	t := &tracer{
+		tracer:     o.TracerProvider.Tracer(ScopeName),
+		propagator: o.Propagator,
+		service:    service,
+		policyUUID: o.PolicyUUID,
+	}
+	if o.ChainID != nil {
+		t.chainID.Store(new(big.Int).Set(o.ChainID))
+	}
+	return t
+}
+
+// start starts the span of a call of method and returns a context sending its trace context to the endpoint.
+func (t *tracer) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, semconv.RPCSystemKey.String("jsonrpc"), semconv.RPCService(t.service), semconv.RPCMethod(method), semconv.RPCJsonrpcVersion("2.0"))
+	if chainID := t.chainID.Load(); chainID != nil {
+		attrs = append(attrs, ChainIDKey.String(chainID.String()))
+	}
+	ctx, span := t.tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	header := make(http.Header)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+	return rpc.NewContextWithHeaders(ctx, header), span
+}
+
+// end records err, if any, on span and ends it.
+func end(span trace.Span, err error) {
+	if err != nil {
+		recordError(span, err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// recordError records err as an event of span, with the details of an *mferrors.Error.
+func recordError(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	attrs = append(attrs, ErrorClassKey.String(mferrors.Class(err)))
+	var e *mferrors.Error
+	if errors.As(err, &e) {
+		if e.RequestID != "" {
+			attrs = append(attrs, RequestIDKey.String(e.RequestID))
+		}
+		if e.Code != 0 {
+			attrs = append(attrs, semconv.RPCJsonrpcErrorCode(e.Code))
+		}
+	}
+	span.RecordError(err, trace.WithAttributes(attrs...))
+}
+
+// uuidAttr returns the attribute of id, or no attribute if id is nil.
+func uuidAttr(key attribute.Key, id uuid.UUID) []attribute.KeyValue {
+	if id == uuid.Nil {
+		return nil
+	}
+	return []attribute.KeyValue{key.String(id.String())}
+}
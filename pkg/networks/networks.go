@@ -0,0 +1,126 @@
+// Package networks holds the MegaFuel endpoints of the supported networks.
+package networks
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// APIKeyPlaceholder is the slot of the API key in the URL templates of a Network.
+const APIKeyPlaceholder = "{apikey}"
+
+// ErrChainIDMismatch is returned when an endpoint serves another chain than the one of its network.
+var ErrChainIDMismatch = errors.New("chain id mismatch")
+
+// Network describes the MegaFuel endpoints of a chain.
+type Network struct {
+	Name                        string // Name is the identifier of the network, e.g. "bsc-testnet".
+	ChainID                     int64  // ChainID is the chain ID served by the endpoints.
+	PaymasterURL                string // PaymasterURL is the public paymaster endpoint.
+	PrivatePaymasterURLTemplate string // PrivatePaymasterURLTemplate is the private policy paymaster endpoint, with an APIKeyPlaceholder.
+	SponsorURLTemplate          string // SponsorURLTemplate is the sponsor endpoint, with an APIKeyPlaceholder.
+	ExplorerURL                 string // ExplorerURL is the base URL of the block explorer.
+}
+
+var (
+	// BSCMainnet is the BNB Smart Chain mainnet.
+	BSCMainnet = Network{
+		Name:                        "bsc-mainnet",
+		ChainID:                     56,
+		PaymasterURL:                "https://bsc-megafuel.nodereal.io",
+		PrivatePaymasterURLTemplate: "https://open-platform-ap.nodereal.io/" + APIKeyPlaceholder + "/megafuel/56",
+		SponsorURLTemplate:          "https://open-platform-ap.nodereal.io/" + APIKeyPlaceholder + "/megafuel",
+		ExplorerURL:                 "https://bscscan.com",
+	}
+
+	// BSCTestnet is the BNB Smart Chain testnet.
+	BSCTestnet = Network{
+		Name:                        "bsc-testnet",
+		ChainID:                     97,
+		PaymasterURL:                "https://bsc-megafuel-testnet.nodereal.io",
+		PrivatePaymasterURLTemplate: "https://open-platform-ap.nodereal.io/" + APIKeyPlaceholder + "/megafuel-testnet/97",
+		SponsorURLTemplate:          "https://open-platform-ap.nodereal.io/" + APIKeyPlaceholder + "/megafuel-testnet",
+		ExplorerURL:                 "https://testnet.bscscan.com",
+	}
+
+	// OpBNBMainnet is the opBNB mainnet.
+	OpBNBMainnet = Network{
+		Name:                        "opbnb-mainnet",
+		ChainID:                     204,
+		PaymasterURL:                "https://opbnb-megafuel.nodereal.io",
+		PrivatePaymasterURLTemplate: "https://open-platform-ap.nodereal.io/" + APIKeyPlaceholder + "/megafuel/204",
+		SponsorURLTemplate:          "https://open-platform-ap.nodereal.io/" + APIKeyPlaceholder + "/megafuel",
+		ExplorerURL:                 "https://opbnb.bscscan.com",
+	}
+
+	// OpBNBTestnet is the opBNB testnet.
+	OpBNBTestnet = Network{
+		Name:                        "opbnb-testnet",
+		ChainID:                     5611,
+		PaymasterURL:                "https://opbnb-megafuel-testnet.nodereal.io",
+		PrivatePaymasterURLTemplate: "https://open-platform-ap.nodereal.io/" + APIKeyPlaceholder + "/megafuel-testnet/5611",
+		SponsorURLTemplate:          "https://open-platform-ap.nodereal.io/" + APIKeyPlaceholder + "/megafuel-testnet",
+		ExplorerURL:                 "https://opbnb-testnet.bscscan.com",
+	}
+)
+
+// All returns every preset network.
+func All() []Network {
+	return []Network{BSCMainnet, BSCTestnet, OpBNBMainnet, OpBNBTestnet}
+}
+
+// ByName returns the preset network with the given name.
+func ByName(name string) (Network, bool) {
+	for _, n := range All() {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return Network{}, false
+}
+
+// ByChainID returns the preset network with the given chain ID.
+func ByChainID(chainID int64) (Network, bool) {
+	for _, n := range All() {
+		if n.ChainID == chainID {
+			return n, true
+		}
+	}
+	return Network{}, false
+}
+
+// SponsorURL returns the sponsor endpoint for the given API key.
+func (n Network) SponsorURL(apiKey string) string {
+	return strings.ReplaceAll(n.SponsorURLTemplate, APIKeyPlaceholder, apiKey)
+}
+
+// PrivatePaymasterURL returns the private policy paymaster endpoint for the given API key.
+func (n Network) PrivatePaymasterURL(apiKey string) string {
+	return strings.ReplaceAll(n.PrivatePaymasterURLTemplate, APIKeyPlaceholder, apiKey)
+}
+
+// TxURL returns the explorer page of a transaction.
+func (n Network) TxURL(txHash common.Hash) string {
+	return n.ExplorerURL + "/tx/" + txHash.Hex()
+}
+
+// AddressURL returns the explorer page of an address.
+func (n Network) AddressURL(address common.Address) string {
+	return n.ExplorerURL + "/address/" + address.Hex()
+}
+
+// VerifyChainID returns an error matching ErrChainIDMismatch if chainID is not the chain ID of the network.
+func (n Network) VerifyChainID(chainID *big.Int) error {
+	if chainID == nil || !chainID.IsInt64() || chainID.Int64() != n.ChainID {
+		return fmt.Errorf("%w: network %s expects chain id %d, endpoint serves %v", ErrChainIDMismatch, n.Name, n.ChainID, chainID)
+	}
+	return nil
+}
+
+func (n Network) String() string {
+	return n.Name
+}
@@ -0,0 +1,82 @@
+package paymasterclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+)
+
+// BatchChunkSize is the maximum number of calls sent in a single JSON-RPC batch request.
+// Larger inputs are split into several batch requests.
+const BatchChunkSize = 100
+
+// BatchResult is the outcome of a single item of a batch call. Exactly one of Result and Err is set.
+type BatchResult[T any] struct {
+	Result *T
+	Err    error
+}
+
+func (c *client) BatchIsSponsorable(ctx context.Context, txs []TransactionArgs) ([]BatchResult[IsSponsorableResponse], error) {
+	args := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		args[i] = tx
+	}
+	return batchCall[IsSponsorableResponse](c.withHeaders(ctx, nil), c, "pm_isSponsorable", args)
+}
+
+func (c *client) BatchGetGaslessTransactions(ctx context.Context, txHashes []common.Hash) ([]BatchResult[TransactionResponse], error) {
+	return batchCall[TransactionResponse](ctx, c, "eth_getGaslessTransactionByHash", hashArgs(txHashes))
+}
+
+func (c *client) BatchGetSponsorTxByTxHash(ctx context.Context, txHashes []common.Hash) ([]BatchResult[SponsorTx], error) {
+	return batchCall[SponsorTx](ctx, c, "pm_getSponsorTxByTxHash", hashArgs(txHashes))
+}
+
+func hashArgs(hashes []common.Hash) []interface{} {
+	args := make([]interface{}, len(hashes))
+	for i, hash := range hashes {
+		args[i] = hash
+	}
+	return args
+}
+
+// batchCall calls method once per argument, BatchChunkSize calls per batch request. A chunk that fails as
+// a whole marks all of its items with the chunk error, and the first such error is also returned.
+func batchCall[T any](ctx context.Context, c *client, method string, args []interface{}) ([]BatchResult[T], error) {
+	var (
+		results  = make([]BatchResult[T], len(args))
+		firstErr error
+	)
+	for start := 0; start < len(args); start += BatchChunkSize {
+		end := min(start+BatchChunkSize, len(args))
+
+		requestID := mferrors.NewRequestID()
+		chunkCtx := rpc.NewContextWithHeaders(ctx, http.Header{mferrors.RequestIDHeader: {requestID}})
+		elems := make([]rpc.BatchElem, end-start)
+		for i := range elems {
+			results[start+i].Result = new(T)
+			elems[i] = rpc.BatchElem{Method: method, Args: []interface{}{args[start+i]}, Result: results[start+i].Result}
+		}
+
+		if err := c.c.BatchCallContext(chunkCtx, elems); err != nil {
+			err = mferrors.Wrap(method, requestID, err)
+			for i := range elems {
+				results[start+i] = BatchResult[T]{Err: err}
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for i, elem := range elems {
+			if elem.Error != nil {
+				results[start+i] = BatchResult[T]{Err: mferrors.Wrap(method, requestID, elem.Error)}
+			}
+		}
+	}
+	return results, firstErr
+}
@@ -0,0 +1,150 @@
+package paymasterclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+)
+
+// ErrNotBundled is returned by ExploreTransaction for transactions that were never put into a bundle.
+var ErrNotBundled = errors.New("transaction is not in a bundle")
+
+// BlockReader reads blocks of the chain, e.g. an *ethclient.Client connected to a node of the chain.
+type BlockReader interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+// BundleView puts together everything known about a bundle: the bundle itself, its sponsor transaction
+// and the user transactions it carries, with gas accounting per leg.
+//
+// The API cannot list the transactions of a bundle. Once the bundle is confirmed they are found in the block
+// that includes it, so Transactions holds all of them; before that it only holds the candidates passed to
+// ExploreBundle that belong to the bundle.
+type BundleView struct {
+	Bundle       *Bundle
+	SponsorTx    *SponsorTx // SponsorTx is nil until the paymaster has created the sponsor transaction.
+	Transactions []BundleTransaction
+	// Unresolved maps the transactions that could not be looked up to the lookup error.
+	Unresolved map[common.Hash]error
+
+	UserGasUsed   uint64   // UserGasUsed is the gas used by the user transactions in Transactions.
+	UserGasFee    *big.Int // UserGasFee is the sum of the gas fees of the user transactions in Transactions.
+	SponsorGasFee *big.Int // SponsorGasFee is the gas fee paid by the sponsor transaction, zero if unknown.
+}
+
+// BundleTransaction is a user transaction of a bundle.
+type BundleTransaction struct {
+	*TransactionResponse
+	// Transaction is the transaction decoded from RawData, nil if RawData could not be decoded.
+	Transaction *types.Transaction
+	// DecodeErr is the error decoding RawData, if any.
+	DecodeErr error
+}
+
+// ExploreBundle resolves the bundle bundleUUID, its sponsor transaction and its user transactions. Those of
+// a confirmed bundle are the gasless transactions, i.e. with a zero gas price, of the block that includes it,
+// read through chain. The candidate transactions txHashes that belong to the bundle are added, which is the
+// only way to find the transactions of a bundle that is not confirmed yet. Candidates of other bundles are
+// ignored.
+func ExploreBundle(ctx context.Context, client Client, chain BlockReader, bundleUUID uuid.UUID, txHashes ...common.Hash) (*BundleView, error) {
+	bundle, err := client.GetBundleByUUID(ctx, bundleUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle: %w", err)
+	}
+	view := &BundleView{
+		Bundle:        bundle,
+		Unresolved:    make(map[common.Hash]error),
+		UserGasFee:    new(big.Int),
+		SponsorGasFee: new(big.Int),
+	}
+
+	sponsorTx, err := client.GetSponsorTxByBundleUUID(ctx, bundleUUID)
+	switch {
+	case err == nil:
+		view.SponsorTx = sponsorTx
+		if sponsorTx.GasFee != nil {
+			view.SponsorGasFee.Set(sponsorTx.GasFee.Raw())
+		}
+	case !errors.Is(err, mferrors.ErrNotFound):
+		return nil, fmt.Errorf("failed to get sponsor transaction: %w", err)
+	}
+
+	// The transactions of the block come first, in block order, then the candidates.
+	var (
+		lookups    []common.Hash
+		candidates = make(map[common.Hash]bool)
+		seen       = make(map[common.Hash]bool)
+	)
+	if bundle.ConfirmedBlockNumber > 0 {
+		block, err := chain.BlockByNumber(ctx, big.NewInt(bundle.ConfirmedBlockNumber))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %d: %w", bundle.ConfirmedBlockNumber, err)
+		}
+		for _, tx := range block.Transactions() {
+			if tx.GasPrice().Sign() == 0 {
+				lookups = append(lookups, tx.Hash())
+				seen[tx.Hash()] = true
+			}
+		}
+	}
+	for _, txHash := range txHashes {
+		candidates[txHash] = true
+		if !seen[txHash] {
+			lookups = append(lookups, txHash)
+			seen[txHash] = true
+		}
+	}
+	if len(lookups) == 0 {
+		return view, nil
+	}
+	results, err := client.BatchGetGaslessTransactions(ctx, lookups)
+	if err != nil && results == nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	for i, result := range results {
+		// Gasless transactions of the block that the paymaster does not know were not sent through it.
+		if result.Err != nil {
+			if candidates[lookups[i]] || !errors.Is(result.Err, mferrors.ErrNotFound) {
+				view.Unresolved[lookups[i]] = result.Err
+			}
+			continue
+		}
+		tx := result.Result
+		if tx.BundleUUID != bundleUUID {
+			continue
+		}
+
+		btx := BundleTransaction{TransactionResponse: tx}
+		decoded := new(types.Transaction)
+		if btx.DecodeErr = decoded.UnmarshalBinary(tx.RawData); btx.DecodeErr == nil {
+			btx.Transaction = decoded
+		}
+		view.Transactions = append(view.Transactions, btx)
+
+		view.UserGasUsed += tx.GasUsed
+		if tx.GasFee != nil {
+			view.UserGasFee.Add(view.UserGasFee, tx.GasFee.Raw())
+		}
+	}
+	return view, nil
+}
+
+// ExploreTransaction resolves the bundle of the transaction txHash, like ExploreBundle with txHash and
+// the other candidate transactions otherTxHashes, e.g. other transactions sent around the same time.
+func ExploreTransaction(ctx context.Context, client Client, chain BlockReader, txHash common.Hash, otherTxHashes ...common.Hash) (*BundleView, error) {
+	tx, err := client.GetGaslessTransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if tx.BundleUUID == uuid.Nil {
+		return nil, ErrNotBundled
+	}
+	return ExploreBundle(ctx, client, chain, tx.BundleUUID, append([]common.Hash{txHash}, otherTxHashes...)...)
+}
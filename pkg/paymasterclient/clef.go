@@ -0,0 +1,80 @@
+package paymasterclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrSignerMismatch is returned when an external signer returns another transaction than the one requested,
+// or signs it for another account.
+var ErrSignerMismatch = errors.New("external signer returned a different transaction")
+
+// ExternalSigner is a Signer delegating to an external signer speaking the clef JSON-RPC protocol,
+// such as clef itself. Keys never enter the process and every signature can be approved externally.
+type ExternalSigner struct {
+	c       *rpc.Client
+	address common.Address
+}
+
+// NewExternalSigner connects to the external signer at endpoint, e.g. the path of the clef IPC socket
+// or an HTTP URL, to sign for address.
+func NewExternalSigner(ctx context.Context, endpoint string, address common.Address, options ...rpc.ClientOption) (*ExternalSigner, error) {
+	c, err := rpc.DialOptions(ctx, endpoint, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalSigner{c, address}, nil
+}
+
+// Close closes the connection to the external signer.
+func (s *ExternalSigner) Close() {
+	s.c.Close()
+}
+
+func (s *ExternalSigner) Address() common.Address {
+	return s.address
+}
+
+// signTransactionResult is the result of account_signTransaction.
+type signTransactionResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// SignTx asks the external signer to sign tx with account_signTransaction. The signed transaction is
+// checked to be tx, signed by the signer address for chainID.
+func (s *ExternalSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args, err := NewTransactionArgs(s.address, tx)
+	if err != nil {
+		return nil, err
+	}
+	args.ChainID = (*hexutil.Big)(chainID)
+
+	var result signTransactionResult
+	if err := s.c.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("external signer: %w", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("external signer: invalid signed transaction: %w", err)
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, ErrSignerMismatch
+	}
+	from, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, fmt.Errorf("external signer: %w", err)
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("%w: signed by %s instead of %s", ErrSignerMismatch, from, s.address)
+	}
+	return signed, nil
+}
@@ -2,12 +2,19 @@ package paymasterclient
 
 import (
 	"context"
+	"fmt"
 	"math/big"
+	"net/http"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/apikey"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
+	"github.com/node-real/megafuel-go-sdk/pkg/retry"
 )
 
 type Client interface {
@@ -28,6 +35,13 @@ type Client interface {
 	GetBundleByUUID(ctx context.Context, bundleUUID uuid.UUID) (bundle *Bundle, err error)
 	// GetTransactionCount returns the number of transactions sent from an address
 	GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error)
+
+	// BatchIsSponsorable checks if each of the transactions is sponsorable, using JSON-RPC batch requests
+	BatchIsSponsorable(ctx context.Context, txs []TransactionArgs) ([]BatchResult[IsSponsorableResponse], error)
+	// BatchGetGaslessTransactions returns the gasless transactions of the given hashes, using JSON-RPC batch requests
+	BatchGetGaslessTransactions(ctx context.Context, txHashes []common.Hash) ([]BatchResult[TransactionResponse], error)
+	// BatchGetSponsorTxByTxHash returns the sponsor transactions of the given hashes, using JSON-RPC batch requests
+	BatchGetSponsorTxByTxHash(ctx context.Context, txHashes []common.Hash) ([]BatchResult[SponsorTx], error)
 }
 
 type client struct {
@@ -38,9 +52,9 @@ type client struct {
 // New creates a new Client with the given URL and options.
 // The URL is typically in the format of https://bsc-megafuel.nodereal.io/
 func New(ctx context.Context, url string, options ...rpc.ClientOption) (Client, error) {
-	c, err := rpc.DialOptions(ctx, url, options...)
+	c, err := rpc.DialOptions(ctx, url, withDefaultOptions(options)...)
 	if err != nil {
-		return nil, err
+		return nil, apikey.RedactError(err)
 	}
 
 	return &client{c, nil}, nil
@@ -50,17 +64,68 @@ func New(ctx context.Context, url string, options ...rpc.ClientOption) (Client,
 // The URL for this function should be in the format:
 // https://open-platform-ap.nodereal.io/{$apikey}/megafuel
 func NewPrivatePaymaster(ctx context.Context, url, privatePolicyUUID string, options ...rpc.ClientOption) (Client, error) {
-	c, err := rpc.DialOptions(ctx, url, options...)
+	c, err := rpc.DialOptions(ctx, url, withDefaultOptions(options)...)
 	if err != nil {
-		return nil, err
+		return nil, apikey.RedactError(err)
 	}
 
 	return &client{c, &privatePolicyUUID}, nil
 }
 
+// NewPrivatePaymasterWithCredential creates a new Client with private policy functionality, taking the
+// API key from cred for every request. urlTemplate has the networks.APIKeyPlaceholder slot of the key, e.g.
+// https://open-platform-ap.nodereal.io/{apikey}/megafuel/97. A custom http.Client passed in options must
+// wrap its transport with apikey.NewTransport.
+func NewPrivatePaymasterWithCredential(ctx context.Context, urlTemplate string, cred apikey.Credential, privatePolicyUUID string, options ...rpc.ClientOption) (Client, error) {
+	c, err := dialWithCredential(ctx, urlTemplate, cred, options)
+	if err != nil {
+		return nil, err
+	}
+	return &client{c, &privatePolicyUUID}, nil
+}
+
+// NewForNetwork creates a new Client for the public paymaster of network.
+// It fails if the endpoint does not serve the chain of network.
+func NewForNetwork(ctx context.Context, network networks.Network, options ...rpc.ClientOption) (Client, error) {
+	c, err := rpc.DialOptions(ctx, network.PaymasterURL, withDefaultOptions(options)...)
+	if err != nil {
+		return nil, apikey.RedactError(err)
+	}
+	return verifyNetwork(ctx, &client{c, nil}, network)
+}
+
+// NewPrivatePaymasterForNetwork creates a new Client with private policy functionality for network.
+// It fails if the endpoint does not serve the chain of network.
+func NewPrivatePaymasterForNetwork(ctx context.Context, network networks.Network, apiKey, privatePolicyUUID string, options ...rpc.ClientOption) (Client, error) {
+	return NewPrivatePaymasterForNetworkWithCredential(ctx, network, apikey.Static(apiKey), privatePolicyUUID, options...)
+}
+
+// NewPrivatePaymasterForNetworkWithCredential is NewPrivatePaymasterForNetwork taking the API key from cred
+// for every request, see NewPrivatePaymasterWithCredential.
+func NewPrivatePaymasterForNetworkWithCredential(ctx context.Context, network networks.Network, cred apikey.Credential, privatePolicyUUID string, options ...rpc.ClientOption) (Client, error) {
+	c, err := dialWithCredential(ctx, network.PrivatePaymasterURLTemplate, cred, options)
+	if err != nil {
+		return nil, err
+	}
+	return verifyNetwork(ctx, &client{c, &privatePolicyUUID}, network)
+}
+
+// verifyNetwork checks that c serves the chain of network, and closes it otherwise.
+func verifyNetwork(ctx context.Context, c *client, network networks.Network) (Client, error) {
+	chainID, err := c.ChainID(ctx)
+	if err == nil {
+		err = network.VerifyChainID(chainID)
+	}
+	if err != nil {
+		c.c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
 func (c *client) ChainID(ctx context.Context) (*big.Int, error) {
 	var result hexutil.Big
-	err := c.c.CallContext(ctx, &result, "eth_chainId")
+	err := c.call(ctx, &result, "eth_chainId")
 	if err != nil {
 		return nil, err
 	}
@@ -69,12 +134,7 @@ func (c *client) ChainID(ctx context.Context) (*big.Int, error) {
 
 func (c *client) IsSponsorable(ctx context.Context, tx TransactionArgs) (*IsSponsorableResponse, error) {
 	var result IsSponsorableResponse
-
-	if c.PrivatePolicyUUID != nil {
-		c.c.SetHeader("X-MegaFuel-Policy-Uuid", *c.PrivatePolicyUUID)
-	}
-
-	err := c.c.CallContext(ctx, &result, "pm_isSponsorable", tx)
+	err := c.call(c.withHeaders(ctx, nil), &result, "pm_isSponsorable", tx)
 	if err != nil {
 		return nil, err
 	}
@@ -84,18 +144,7 @@ func (c *client) IsSponsorable(ctx context.Context, tx TransactionArgs) (*IsSpon
 
 func (c *client) SendRawTransaction(ctx context.Context, input hexutil.Bytes, opts *TransactionOptions) (common.Hash, error) {
 	var result common.Hash
-
-	if opts != nil {
-		if opts.UserAgent != "" {
-			c.c.SetHeader("User-Agent", opts.UserAgent)
-		}
-	}
-
-	if c.PrivatePolicyUUID != nil {
-		c.c.SetHeader("X-MegaFuel-Policy-Uuid", *c.PrivatePolicyUUID)
-	}
-
-	err := c.c.CallContext(ctx, &result, "eth_sendRawTransaction", input)
+	err := c.call(c.withHeaders(ctx, opts), &result, "eth_sendRawTransaction", input)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -105,7 +154,7 @@ func (c *client) SendRawTransaction(ctx context.Context, input hexutil.Bytes, op
 
 func (c *client) GetGaslessTransactionByHash(ctx context.Context, txHash common.Hash) (*TransactionResponse, error) {
 	var result TransactionResponse
-	err := c.c.CallContext(ctx, &result, "eth_getGaslessTransactionByHash", txHash)
+	err := c.call(ctx, &result, "eth_getGaslessTransactionByHash", txHash)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +163,7 @@ func (c *client) GetGaslessTransactionByHash(ctx context.Context, txHash common.
 
 func (c *client) GetSponsorTxByTxHash(ctx context.Context, txHash common.Hash) (*SponsorTx, error) {
 	var result SponsorTx
-	err := c.c.CallContext(ctx, &result, "pm_getSponsorTxByTxHash", txHash)
+	err := c.call(ctx, &result, "pm_getSponsorTxByTxHash", txHash)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +172,7 @@ func (c *client) GetSponsorTxByTxHash(ctx context.Context, txHash common.Hash) (
 
 func (c *client) GetSponsorTxByBundleUUID(ctx context.Context, bundleUUID uuid.UUID) (*SponsorTx, error) {
 	var result SponsorTx
-	err := c.c.CallContext(ctx, &result, "pm_getSponsorTxByBundleUuid", bundleUUID)
+	err := c.call(ctx, &result, "pm_getSponsorTxByBundleUuid", bundleUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -132,7 +181,7 @@ func (c *client) GetSponsorTxByBundleUUID(ctx context.Context, bundleUUID uuid.U
 
 func (c *client) GetBundleByUUID(ctx context.Context, bundleUUID uuid.UUID) (*Bundle, error) {
 	var result Bundle
-	err := c.c.CallContext(ctx, &result, "pm_getBundleByUuid", bundleUUID)
+	err := c.call(ctx, &result, "pm_getBundleByUuid", bundleUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -141,9 +190,32 @@ func (c *client) GetBundleByUUID(ctx context.Context, bundleUUID uuid.UUID) (*Bu
 
 func (c *client) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
 	var result hexutil.Uint64
-	err := c.c.CallContext(ctx, &result, "eth_getTransactionCount", address, blockNrOrHash)
+	err := c.call(ctx, &result, "eth_getTransactionCount", address, blockNrOrHash)
 	if err != nil {
 		return 0, err
 	}
 	return uint64(result), nil
 }
+
+// call invokes method with a fresh request ID and classifies any failure into an *mferrors.Error.
+func (c *client) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	requestID := mferrors.NewRequestID()
+	ctx = rpc.NewContextWithHeaders(ctx, http.Header{mferrors.RequestIDHeader: {requestID}})
+	return mferrors.Wrap(method, requestID, c.c.CallContext(ctx, result, method, args...))
+}
+
+// dialWithCredential dials urlTemplate with an HTTP client putting the API key of cred in its slot.
+func dialWithCredential(ctx context.Context, urlTemplate string, cred apikey.Credential, options []rpc.ClientOption) (*rpc.Client, error) {
+	if !apikey.HasPlaceholder(urlTemplate) {
+		return nil, fmt.Errorf("url template %s has no %s slot", apikey.URL(urlTemplate), networks.APIKeyPlaceholder)
+	}
+	httpClient := &http.Client{Transport: apikey.NewTransport(cred, retry.NewTransport(nil))}
+	c, err := rpc.DialOptions(ctx, urlTemplate, withDefaultOptions(append([]rpc.ClientOption{rpc.WithHTTPClient(httpClient)}, options...))...)
+	return c, apikey.RedactError(err)
+}
+
+// withDefaultOptions puts the default client options in front of options, so that options can override them.
+// The default HTTP client reports Retry-After hints to the retry layer.
+func withDefaultOptions(options []rpc.ClientOption) []rpc.ClientOption {
+	return append([]rpc.ClientOption{rpc.WithHTTPClient(retry.NewHTTPClient())}, options...)
+}
@@ -0,0 +1,69 @@
+package paymasterclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	headerPolicyUUID = "X-MegaFuel-Policy-Uuid"
+	headerUserAgent  = "User-Agent"
+)
+
+type callOptionsKey struct{}
+
+// callOptions holds the per-call settings carried by a context.
+type callOptions struct {
+	policyUUID string
+	userAgent  string
+}
+
+func callOptionsFromContext(ctx context.Context) callOptions {
+	o, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	return o
+}
+
+// WithPolicyUUID returns a copy of ctx that makes IsSponsorable and SendRawTransaction calls
+// made with it use the given private policy, overriding the policy of the client.
+func WithPolicyUUID(ctx context.Context, policyUUID string) context.Context {
+	o := callOptionsFromContext(ctx)
+	o.policyUUID = policyUUID
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+// PolicyUUIDFromContext returns the private policy set on ctx with WithPolicyUUID, if any.
+func PolicyUUIDFromContext(ctx context.Context) (string, bool) {
+	o := callOptionsFromContext(ctx)
+	return o.policyUUID, o.policyUUID != ""
+}
+
+// WithUserAgent returns a copy of ctx that makes IsSponsorable and SendRawTransaction calls
+// made with it send the given User-Agent header. TransactionOptions.UserAgent takes precedence.
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	o := callOptionsFromContext(ctx)
+	o.userAgent = userAgent
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+// withHeaders attaches the headers of a single call to ctx. The headers travel with the request
+// instead of being set on the shared rpc.Client, so concurrent calls never see each other's values.
+func (c *client) withHeaders(ctx context.Context, opts *TransactionOptions) context.Context {
+	o := callOptionsFromContext(ctx)
+	if o.policyUUID == "" && c.PrivatePolicyUUID != nil {
+		o.policyUUID = *c.PrivatePolicyUUID
+	}
+	if opts != nil && opts.UserAgent != "" {
+		o.userAgent = opts.UserAgent
+	}
+
+	h := make(http.Header, 2)
+	if o.policyUUID != "" {
+		h.Set(headerPolicyUUID, o.policyUUID)
+	}
+	if o.userAgent != "" {
+		h.Set(headerUserAgent, o.userAgent)
+	}
+	return rpc.NewContextWithHeaders(ctx, h)
+}
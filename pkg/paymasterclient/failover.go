@@ -0,0 +1,382 @@
+package paymasterclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/retry"
+)
+
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+
+	// maxRememberedSends bounds the number of transaction hashes remembered for routing.
+	maxRememberedSends = 10000
+)
+
+// ErrNoEndpoints is returned by NewFailoverClient when no endpoint is given.
+var ErrNoEndpoints = errors.New("no endpoints")
+
+// Endpoint is a named paymaster endpoint of a FailoverClient.
+type Endpoint struct {
+	Name   string // Name identifies the endpoint in errors and health reports, e.g. its URL without API key.
+	Client Client
+}
+
+// FailoverOptions defines the options for NewFailoverClient.
+type FailoverOptions struct {
+	// HealthCheckInterval is the delay between two background health checks. Default value is 30s,
+	// a negative value disables background health checks.
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout bounds a single ChainID health check. Default value is 5s.
+	HealthCheckTimeout time.Duration
+	// ChainID is an optional field; endpoints reporting another chain ID are unhealthy.
+	ChainID *big.Int
+}
+
+// EndpointHealth is the health state of an endpoint.
+type EndpointHealth struct {
+	Name      string
+	Healthy   bool
+	LastError error     // LastError is the error that made the endpoint unhealthy.
+	CheckedAt time.Time // CheckedAt is the time of the last health check.
+}
+
+type endpointState struct {
+	Endpoint
+	mu     sync.Mutex
+	health EndpointHealth
+}
+
+func (e *endpointState) setHealth(healthy bool, err error, checked bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.health.Healthy = healthy
+	e.health.LastError = err
+	if checked {
+		e.health.CheckedAt = time.Now()
+	}
+}
+
+func (e *endpointState) healthy() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.health.Healthy
+}
+
+// FailoverClient is a Client spreading calls over several endpoints in priority order. Endpoints are
+// health checked with ChainID and calls go to the first healthy one. Reads fail over to the next endpoint
+// on transient failures. A raw transaction is only sent to another endpoint when the failure shows it
+// never reached the first one, and a transaction sent again is always routed to the endpoint that got it
+// first, so the same transaction is never sent to two endpoints by accident; concurrent sends of the same
+// transaction wait for the first one. Lookups by transaction hash are routed to that endpoint too.
+type FailoverClient struct {
+	endpoints []*endpointState
+	opts      FailoverOptions
+
+	mu        sync.Mutex
+	sentTo    map[common.Hash]*endpointState
+	sentOrder []common.Hash
+	sending   map[common.Hash]chan struct{} // sending holds the transactions being sent, closed once sent.
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewFailoverClient creates a FailoverClient over endpoints, listed by priority. It checks the health of
+// every endpoint before returning and, unless disabled, keeps checking in the background until Close.
+func NewFailoverClient(ctx context.Context, endpoints []Endpoint, opts *FailoverOptions) (*FailoverClient, error) {
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+	f := &FailoverClient{
+		sentTo:  make(map[common.Hash]*endpointState),
+		sending: make(map[common.Hash]chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if opts != nil {
+		f.opts = *opts
+	}
+	if f.opts.HealthCheckInterval == 0 {
+		f.opts.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if f.opts.HealthCheckTimeout <= 0 {
+		f.opts.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+	for _, endpoint := range endpoints {
+		f.endpoints = append(f.endpoints, &endpointState{Endpoint: endpoint, health: EndpointHealth{Name: endpoint.Name}})
+	}
+
+	f.CheckHealth(ctx)
+	if f.opts.HealthCheckInterval > 0 {
+		go f.healthLoop()
+	} else {
+		close(f.done)
+	}
+	return f, nil
+}
+
+// Close stops the background health checks.
+func (f *FailoverClient) Close() {
+	select {
+	case <-f.stop:
+	default:
+		close(f.stop)
+	}
+	<-f.done
+}
+
+func (f *FailoverClient) healthLoop() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.opts.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			f.CheckHealth(context.Background())
+		}
+	}
+}
+
+// CheckHealth checks every endpoint concurrently with ChainID and updates their health.
+func (f *FailoverClient) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range f.endpoints {
+		wg.Add(1)
+		go func(e *endpointState) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, f.opts.HealthCheckTimeout)
+			defer cancel()
+			chainID, err := e.Client.ChainID(ctx)
+			if err == nil && f.opts.ChainID != nil && chainID.Cmp(f.opts.ChainID) != 0 {
+				err = fmt.Errorf("chain id mismatch: expected %s, got %s", f.opts.ChainID, chainID)
+			}
+			e.setHealth(err == nil, err, true)
+		}(e)
+	}
+	wg.Wait()
+}
+
+// Health returns the health state of every endpoint, in priority order.
+func (f *FailoverClient) Health() []EndpointHealth {
+	health := make([]EndpointHealth, len(f.endpoints))
+	for i, e := range f.endpoints {
+		e.mu.Lock()
+		health[i] = e.health
+		e.mu.Unlock()
+	}
+	return health
+}
+
+// candidates returns the healthy endpoints in priority order, or all endpoints if none is healthy.
+func (f *FailoverClient) candidates() []*endpointState {
+	var healthy []*endpointState
+	for _, e := range f.endpoints {
+		if e.healthy() {
+			healthy = append(healthy, e)
+		}
+	}
+	if len(healthy) == 0 {
+		return f.endpoints
+	}
+	return healthy
+}
+
+// preferred returns the candidates with the endpoint that got txHash, if any, moved to the front.
+func (f *FailoverClient) preferred(txHash common.Hash) []*endpointState {
+	candidates := f.candidates()
+
+	f.mu.Lock()
+	first, ok := f.sentTo[txHash]
+	f.mu.Unlock()
+	if !ok {
+		return candidates
+	}
+	ordered := []*endpointState{first}
+	for _, e := range candidates {
+		if e != first {
+			ordered = append(ordered, e)
+		}
+	}
+	return ordered
+}
+
+// failoverCall calls fn on the endpoints in order until one succeeds or fails with an error that is not
+// transient. Endpoints failing with a transient error are marked unhealthy.
+func failoverCall[T any](endpoints []*endpointState, fn func(c Client) (T, error)) (T, error) {
+	var (
+		result T
+		err    error
+	)
+	for _, e := range endpoints {
+		result, err = fn(e.Client)
+		if err == nil || !mferrors.IsRetryable(err) {
+			return result, err
+		}
+		e.setHealth(false, err, false)
+	}
+	return result, err
+}
+
+func (f *FailoverClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return failoverCall(f.candidates(), func(c Client) (*big.Int, error) {
+		return c.ChainID(ctx)
+	})
+}
+
+func (f *FailoverClient) IsSponsorable(ctx context.Context, tx TransactionArgs) (*IsSponsorableResponse, error) {
+	return failoverCall(f.candidates(), func(c Client) (*IsSponsorableResponse, error) {
+		return c.IsSponsorable(ctx, tx)
+	})
+}
+
+func (f *FailoverClient) SendRawTransaction(ctx context.Context, input hexutil.Bytes, opts *TransactionOptions) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		// Without a hash the transaction cannot be tracked, so never fail over.
+		return f.candidates()[0].Client.SendRawTransaction(ctx, input, opts)
+	}
+	txHash := tx.Hash()
+
+	// Concurrent sends of the same transaction wait for the first one, so that they all go to the
+	// endpoint it ends up on.
+	f.mu.Lock()
+	for {
+		sending, ok := f.sending[txHash]
+		if !ok {
+			break
+		}
+		f.mu.Unlock()
+		select {
+		case <-sending:
+		case <-ctx.Done():
+			return common.Hash{}, mferrors.Wrap("eth_sendRawTransaction", "", ctx.Err())
+		}
+		f.mu.Lock()
+	}
+	if e, ok := f.sentTo[txHash]; ok {
+		f.mu.Unlock()
+		return e.Client.SendRawTransaction(ctx, input, opts)
+	}
+	sending := make(chan struct{})
+	f.sending[txHash] = sending
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		delete(f.sending, txHash)
+		f.mu.Unlock()
+		close(sending)
+	}()
+
+	var err error
+	for _, e := range f.candidates() {
+		// Claim the hash for the endpoint before sending, so that lookups are routed to it.
+		f.mu.Lock()
+		f.remember(txHash, e)
+		f.mu.Unlock()
+
+		var hash common.Hash
+		hash, err = e.Client.SendRawTransaction(ctx, input, opts)
+		if err == nil || !retry.IsNotSent(err) {
+			return hash, err
+		}
+
+		// The endpoint never got the transaction, release it for the next one.
+		e.setHealth(false, err, false)
+		f.mu.Lock()
+		f.forget(txHash)
+		f.mu.Unlock()
+	}
+	return common.Hash{}, err
+}
+
+// remember must be called with f.mu held.
+func (f *FailoverClient) remember(txHash common.Hash, e *endpointState) {
+	if _, ok := f.sentTo[txHash]; !ok {
+		f.sentOrder = append(f.sentOrder, txHash)
+	}
+	f.sentTo[txHash] = e
+	for len(f.sentOrder) > maxRememberedSends {
+		delete(f.sentTo, f.sentOrder[0])
+		f.sentOrder = f.sentOrder[1:]
+	}
+}
+
+// forget must be called with f.mu held.
+func (f *FailoverClient) forget(txHash common.Hash) {
+	if _, ok := f.sentTo[txHash]; !ok {
+		return
+	}
+	delete(f.sentTo, txHash)
+	for i, hash := range f.sentOrder {
+		if hash == txHash {
+			f.sentOrder = append(f.sentOrder[:i], f.sentOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+func (f *FailoverClient) GetGaslessTransactionByHash(ctx context.Context, txHash common.Hash) (*TransactionResponse, error) {
+	return failoverCall(f.preferred(txHash), func(c Client) (*TransactionResponse, error) {
+		return c.GetGaslessTransactionByHash(ctx, txHash)
+	})
+}
+
+func (f *FailoverClient) GetSponsorTxByTxHash(ctx context.Context, txHash common.Hash) (*SponsorTx, error) {
+	return failoverCall(f.preferred(txHash), func(c Client) (*SponsorTx, error) {
+		return c.GetSponsorTxByTxHash(ctx, txHash)
+	})
+}
+
+func (f *FailoverClient) GetSponsorTxByBundleUUID(ctx context.Context, bundleUUID uuid.UUID) (*SponsorTx, error) {
+	return failoverCall(f.candidates(), func(c Client) (*SponsorTx, error) {
+		return c.GetSponsorTxByBundleUUID(ctx, bundleUUID)
+	})
+}
+
+func (f *FailoverClient) GetBundleByUUID(ctx context.Context, bundleUUID uuid.UUID) (*Bundle, error) {
+	return failoverCall(f.candidates(), func(c Client) (*Bundle, error) {
+		return c.GetBundleByUUID(ctx, bundleUUID)
+	})
+}
+
+func (f *FailoverClient) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	return failoverCall(f.candidates(), func(c Client) (uint64, error) {
+		return c.GetTransactionCount(ctx, address, blockNrOrHash)
+	})
+}
+
+func (f *FailoverClient) BatchIsSponsorable(ctx context.Context, txs []TransactionArgs) ([]BatchResult[IsSponsorableResponse], error) {
+	return failoverCall(f.candidates(), func(c Client) ([]BatchResult[IsSponsorableResponse], error) {
+		return c.BatchIsSponsorable(ctx, txs)
+	})
+}
+
+func (f *FailoverClient) BatchGetGaslessTransactions(ctx context.Context, txHashes []common.Hash) ([]BatchResult[TransactionResponse], error) {
+	return failoverCall(f.candidates(), func(c Client) ([]BatchResult[TransactionResponse], error) {
+		return c.BatchGetGaslessTransactions(ctx, txHashes)
+	})
+}
+
+func (f *FailoverClient) BatchGetSponsorTxByTxHash(ctx context.Context, txHashes []common.Hash) ([]BatchResult[SponsorTx], error) {
+	return failoverCall(f.candidates(), func(c Client) ([]BatchResult[SponsorTx], error) {
+		return c.BatchGetSponsorTxByTxHash(ctx, txHashes)
+	})
+}
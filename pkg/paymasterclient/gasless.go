@@ -0,0 +1,218 @@
+package paymasterclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+)
+
+// defaultTransferGas is the gas limit used for plain value transfers when GaslessRequest.Gas is not set.
+const defaultTransferGas = 21000
+
+// ErrNotSponsorable is returned by GaslessSender.Send when the paymaster declines to sponsor the transaction.
+// It is the same error as mferrors.ErrNotSponsorable.
+var ErrNotSponsorable = mferrors.ErrNotSponsorable
+
+// ErrGasRequired is returned by GaslessSender.Send when a request carrying call data has no gas limit.
+var ErrGasRequired = errors.New("gas limit is required for transactions with data")
+
+// UnsponsorableReason explains why a gasless transaction was not sent.
+type UnsponsorableReason string
+
+const (
+	// ReasonNone means the transaction was sponsorable.
+	ReasonNone UnsponsorableReason = ""
+
+	// ReasonRejectedByPolicy means pm_isSponsorable reported the transaction as not sponsorable.
+	ReasonRejectedByPolicy UnsponsorableReason = "RejectedByPolicy"
+
+	// ReasonPolicyExhausted means the sponsor policy has run out of budget or quota.
+	ReasonPolicyExhausted UnsponsorableReason = "PolicyExhausted"
+)
+
+// unsponsorableReason returns the reason matching an error returned by the paymaster, if any.
+func unsponsorableReason(err error) UnsponsorableReason {
+	switch {
+	case errors.Is(err, mferrors.ErrPolicyExhausted):
+		return ReasonPolicyExhausted
+	case errors.Is(err, mferrors.ErrNotSponsorable):
+		return ReasonRejectedByPolicy
+	}
+	return ReasonNone
+}
+
+// GaslessRequest describes a transaction to be sent through the paymaster.
+type GaslessRequest struct {
+	To    *common.Address // To is the recipient, nil for contract creation.
+	Value *big.Int        // Value is the amount of wei to transfer, nil means zero.
+	Data  []byte          // Data is the call data.
+	Gas   uint64          // Gas is the gas limit. Zero defaults to 21000 for transactions without data.
+}
+
+// GaslessSenderOptions defines the options for a GaslessSender.
+type GaslessSenderOptions struct {
+	// UserAgent is an optional field to set a custom User-Agent header for SendRawTransaction.
+	UserAgent string
+	// NonceManager is an optional field to take nonces from. When nil, every Send asks GetTransactionCount.
+	// Share one NonceManager between all senders of an account.
+	NonceManager *NonceManager
+}
+
+// GaslessResult is the outcome of GaslessSender.Send.
+type GaslessResult struct {
+	TxHash      common.Hash            // TxHash is the hash returned by SendRawTransaction. Empty if the tx was not sent.
+	Transaction *types.Transaction     // Transaction is the signed transaction. Nil if the tx was not sent.
+	Sponsor     *IsSponsorableResponse // Sponsor is the sponsorship information returned by IsSponsorable.
+	Reason      UnsponsorableReason    // Reason is set when the tx was not sponsorable.
+}
+
+// GaslessSender runs the whole gasless flow for a single account: it builds a zero gas price
+// transaction, checks that it is sponsorable, signs it and sends it to the paymaster.
+type GaslessSender struct {
+	client Client
+	signer Signer
+	opts   GaslessSenderOptions
+
+	mu      sync.Mutex
+	chainID *big.Int
+}
+
+// NewGaslessSender creates a GaslessSender that sends transactions signed by signer through client.
+func NewGaslessSender(client Client, signer Signer, opts *GaslessSenderOptions) *GaslessSender {
+	s := &GaslessSender{client: client, signer: signer}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return s
+}
+
+// Address returns the account the sender sends from.
+func (s *GaslessSender) Address() common.Address {
+	return s.signer.Address()
+}
+
+// ChainID returns the chain ID of the paymaster, fetching it on first use.
+func (s *GaslessSender) ChainID(ctx context.Context) (*big.Int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.chainID == nil {
+		chainID, err := s.client.ChainID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.chainID = chainID
+	}
+	return new(big.Int).Set(s.chainID), nil
+}
+
+// Send builds, checks, signs and sends a gasless transaction. When the paymaster declines to
+// sponsor the transaction, the returned result carries the reason and, if available, the
+// sponsorship information, and the error matches ErrNotSponsorable.
+func (s *GaslessSender) Send(ctx context.Context, req GaslessRequest) (*GaslessResult, error) {
+	gas := req.Gas
+	if gas == 0 {
+		if len(req.Data) > 0 {
+			return nil, ErrGasRequired
+		}
+		gas = defaultTransferGas
+	}
+	value := req.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	chainID, err := s.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain id: %w", err)
+	}
+
+	from := s.signer.Address()
+	nonce, err := s.nonce(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	result, sent, err := s.send(ctx, from, nonce, chainID, gas, value, req)
+	if err != nil && s.opts.NonceManager != nil {
+		if sent {
+			// The nonce may have been consumed, let the manager decide from the error.
+			_ = s.opts.NonceManager.HandleSendError(ctx, from, nonce, err)
+		} else {
+			s.opts.NonceManager.Release(from, nonce)
+		}
+	}
+	return result, err
+}
+
+func (s *GaslessSender) nonce(ctx context.Context, from common.Address) (uint64, error) {
+	if s.opts.NonceManager != nil {
+		return s.opts.NonceManager.Next(ctx, from)
+	}
+	blockNumber := rpc.PendingBlockNumber
+	return s.client.GetTransactionCount(ctx, from, rpc.BlockNumberOrHash{BlockNumber: &blockNumber})
+}
+
+func (s *GaslessSender) send(ctx context.Context, from common.Address, nonce uint64, chainID *big.Int, gas uint64, value *big.Int, req GaslessRequest) (result *GaslessResult, sent bool, err error) {
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: big.NewInt(0),
+		Gas:      gas,
+		To:       req.To,
+		Value:    value,
+		Data:     req.Data,
+	})
+
+	args, err := NewTransactionArgs(from, tx)
+	if err != nil {
+		return nil, false, err
+	}
+	// The transaction is signed for chainID, which the unsigned legacy transaction does not carry yet.
+	args.ChainID = (*hexutil.Big)(chainID)
+	sponsor, err := s.client.IsSponsorable(ctx, args)
+	if err != nil {
+		if reason := unsponsorableReason(err); reason != ReasonNone {
+			return &GaslessResult{Reason: reason}, false, fmt.Errorf("%w: %w", ErrNotSponsorable, err)
+		}
+		return nil, false, fmt.Errorf("failed to check sponsorable: %w", err)
+	}
+	result = &GaslessResult{Sponsor: sponsor}
+	if !sponsor.Sponsorable {
+		result.Reason = ReasonRejectedByPolicy
+		return result, false, ErrNotSponsorable
+	}
+
+	signedTx, err := s.signer.SignTx(ctx, tx, chainID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	input, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal transaction: %w", err)
+	}
+
+	var opts *TransactionOptions
+	if s.opts.UserAgent != "" {
+		opts = &TransactionOptions{UserAgent: s.opts.UserAgent}
+	}
+	txHash, err := s.client.SendRawTransaction(ctx, input, opts)
+	if err != nil {
+		if reason := unsponsorableReason(err); reason != ReasonNone {
+			result.Reason = reason
+			return result, true, fmt.Errorf("%w: %w", ErrNotSponsorable, err)
+		}
+		return nil, true, fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	result.TxHash = txHash
+	result.Transaction = signedTx
+	return result, true, nil
+}
@@ -0,0 +1,106 @@
+package paymasterclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultDerivationPath is the BIP44 path of the first Ethereum account, m/44'/60'/0'/0/0.
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+var (
+	// ErrInvalidMnemonic is returned by NewHDWalletSigner for a mnemonic with a wrong number of words, a word
+	// missing from the BIP39 English wordlist or a wrong checksum, e.g. because of a mistyped word.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// errInvalidChildKey is returned for the rare derivation indices that give no valid key, see BIP32.
+	errInvalidChildKey = errors.New("invalid child key, use the next index")
+)
+
+// NewHDWalletSigner creates a Signer for the account at path, e.g. DefaultDerivationPath, of the HD wallet
+// with the given BIP39 mnemonic and optional passphrase. The mnemonic must be made of words of the BIP39
+// English wordlist and have a valid checksum.
+func NewHDWalletSigner(mnemonic, passphrase, path string) (Signer, error) {
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := mnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(seed, derivationPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKeySigner(key), nil
+}
+
+// mnemonicSeed checks a mnemonic and returns its BIP39 seed. Errors never include the words.
+func mnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	for i, word := range words {
+		if _, ok := bip39.GetWordIndex(word); !ok {
+			return nil, fmt.Errorf("%w: word %d is not in the wordlist", ErrInvalidMnemonic, i+1)
+		}
+	}
+	mnemonic = strings.Join(words, " ")
+	if _, err := bip39.EntropyFromMnemonic(mnemonic); err != nil {
+		if errors.Is(err, bip39.ErrChecksumIncorrect) {
+			return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidMnemonic)
+		}
+		return nil, fmt.Errorf("%w: %d words, want 12, 15, 18, 21 or 24", ErrInvalidMnemonic, len(words))
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// deriveKey derives the private key at path from a BIP32 seed.
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+
+	n := crypto.S256().Params().N
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, errInvalidChildKey
+	}
+	for _, index := range path {
+		mac := hmac.New(sha512.New, chainCode)
+		if index >= 0x80000000 {
+			// Hardened child: 0x00 || ser256(k) || ser32(i).
+			mac.Write([]byte{0})
+			mac.Write(key.FillBytes(make([]byte, 32)))
+		} else {
+			// Normal child: serP(point(k)) || ser32(i).
+			private, err := crypto.ToECDSA(key.FillBytes(make([]byte, 32)))
+			if err != nil {
+				return nil, err
+			}
+			mac.Write(crypto.CompressPubkey(&private.PublicKey))
+		}
+		mac.Write(binary.BigEndian.AppendUint32(nil, index))
+		sum := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, errInvalidChildKey
+		}
+		key = tweak.Add(tweak, key).Mod(tweak, n)
+		if key.Sign() == 0 {
+			return nil, errInvalidChildKey
+		}
+		chainCode = sum[32:]
+	}
+	return crypto.ToECDSA(key.FillBytes(make([]byte, 32)))
+}
@@ -0,0 +1,151 @@
+package paymasterclient
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/retry"
+)
+
+// NonceManager hands out consecutive nonces per account without asking the paymaster every time.
+// Sponsored transactions wait in bundles and are not reflected by GetTransactionCount right away,
+// so sending several transactions quickly from one account would otherwise reuse nonces.
+// It is safe for concurrent use.
+type NonceManager struct {
+	client Client
+
+	mu       sync.Mutex
+	accounts map[common.Address]*accountNonces
+}
+
+type accountNonces struct {
+	mu       sync.Mutex
+	seeded   bool
+	next     uint64
+	released []uint64 // released holds nonces returned by Release that are lower than next, in ascending order.
+}
+
+// NewNonceManager creates a NonceManager seeding its nonces from client.
+func NewNonceManager(client Client) *NonceManager {
+	return &NonceManager{client: client, accounts: make(map[common.Address]*accountNonces)}
+}
+
+func (m *NonceManager) account(address common.Address) *accountNonces {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.accounts[address]
+	if !ok {
+		a = new(accountNonces)
+		m.accounts[address] = a
+	}
+	return a
+}
+
+// Next returns the next nonce for address. The first call for an address seeds the manager with
+// GetTransactionCount at the pending block. Released nonces are handed out again before new ones.
+func (m *NonceManager) Next(ctx context.Context, address common.Address) (uint64, error) {
+	a := m.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.seeded {
+		if err := m.seed(ctx, address, a, 0); err != nil {
+			return 0, err
+		}
+	}
+	if len(a.released) > 0 {
+		nonce := a.released[0]
+		a.released = a.released[1:]
+		return nonce, nil
+	}
+	nonce := a.next
+	a.next++
+	return nonce, nil
+}
+
+// Release returns a nonce obtained from Next that was not used, e.g. because SendRawTransaction failed,
+// so that it is handed out again.
+func (m *NonceManager) Release(address common.Address, nonce uint64) {
+	a := m.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.seeded || nonce >= a.next {
+		return
+	}
+	if nonce == a.next-1 {
+		a.next--
+		// Fold released nonces that now sit right below next.
+		for len(a.released) > 0 && a.released[len(a.released)-1] == a.next-1 {
+			a.released = a.released[:len(a.released)-1]
+			a.next--
+		}
+		return
+	}
+	i := sort.Search(len(a.released), func(i int) bool { return a.released[i] >= nonce })
+	if i < len(a.released) && a.released[i] == nonce {
+		return
+	}
+	a.released = append(a.released, 0)
+	copy(a.released[i+1:], a.released[i:])
+	a.released[i] = nonce
+}
+
+// Resync drops the local state of address and seeds it again from GetTransactionCount.
+func (m *NonceManager) Resync(ctx context.Context, address common.Address) error {
+	a := m.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return m.seed(ctx, address, a, 0)
+}
+
+// Reset forgets address, the next call to Next seeds it again.
+func (m *NonceManager) Reset(address common.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.accounts, address)
+}
+
+// HandleSendError updates the manager after sending a transaction with nonce failed with err.
+// It resyncs the account when the paymaster reports a nonce that is too low or leaves a gap, and
+// releases the nonce only when err shows the transaction never reached the paymaster, see retry.IsNotSent.
+// On any other error, e.g. ErrAlreadyKnown or a timeout, the transaction may have been accepted, so the
+// nonce stays consumed. After a nonce too low, nonces up to the failed one are never handed out again
+// even if GetTransactionCount lags behind. Use Release if the transaction was not sent at all.
+func (m *NonceManager) HandleSendError(ctx context.Context, address common.Address, nonce uint64, err error) error {
+	switch {
+	case errors.Is(err, mferrors.ErrNonceTooLow):
+		a := m.account(address)
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return m.seed(ctx, address, a, nonce+1)
+	case errors.Is(err, mferrors.ErrNonceTooHigh):
+		return m.Resync(ctx, address)
+	case retry.IsNotSent(err):
+		m.Release(address, nonce)
+	}
+	return nil
+}
+
+// seed sets the next nonce of address to its pending transaction count, or to floor if that is higher.
+// It must be called with a.mu held.
+func (m *NonceManager) seed(ctx context.Context, address common.Address, a *accountNonces, floor uint64) error {
+	blockNumber := rpc.PendingBlockNumber
+	nonce, err := m.client.GetTransactionCount(ctx, address, rpc.BlockNumberOrHash{BlockNumber: &blockNumber})
+	if err != nil {
+		return err
+	}
+	a.seeded = true
+	a.next = max(nonce, floor)
+	a.released = nil
+	return nil
+}
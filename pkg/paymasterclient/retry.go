@@ -0,0 +1,107 @@
+package paymasterclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/retry"
+)
+
+type retryClient struct {
+	c      Client
+	policy *retry.Policy
+}
+
+// NewRetryClient wraps c so that transient failures are retried with the backoff of policy, or
+// retry.DefaultPolicy if nil. Reads and IsSponsorable are retried on any transient failure.
+// SendRawTransaction is only retried when the failure shows the transaction never reached the
+// paymaster; a retry answered with "already known" returns the hash of the transaction.
+func NewRetryClient(c Client, policy *retry.Policy) Client {
+	return &retryClient{c, policy}
+}
+
+func (r *retryClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*big.Int, error) {
+		return r.c.ChainID(ctx)
+	})
+}
+
+func (r *retryClient) IsSponsorable(ctx context.Context, tx TransactionArgs) (*IsSponsorableResponse, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*IsSponsorableResponse, error) {
+		return r.c.IsSponsorable(ctx, tx)
+	})
+}
+
+func (r *retryClient) SendRawTransaction(ctx context.Context, input hexutil.Bytes, opts *TransactionOptions) (common.Hash, error) {
+	attempt := 0
+	return retry.Call(ctx, r.policy, retry.IsNotSent, func(ctx context.Context) (common.Hash, error) {
+		attempt++
+		hash, err := r.c.SendRawTransaction(ctx, input, opts)
+		if attempt > 1 && errors.Is(err, mferrors.ErrAlreadyKnown) {
+			// An earlier attempt went through after all.
+			tx := new(types.Transaction)
+			if tx.UnmarshalBinary(input) == nil {
+				return tx.Hash(), nil
+			}
+		}
+		return hash, err
+	})
+}
+
+func (r *retryClient) GetGaslessTransactionByHash(ctx context.Context, txHash common.Hash) (*TransactionResponse, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*TransactionResponse, error) {
+		return r.c.GetGaslessTransactionByHash(ctx, txHash)
+	})
+}
+
+func (r *retryClient) GetSponsorTxByTxHash(ctx context.Context, txHash common.Hash) (*SponsorTx, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*SponsorTx, error) {
+		return r.c.GetSponsorTxByTxHash(ctx, txHash)
+	})
+}
+
+func (r *retryClient) GetSponsorTxByBundleUUID(ctx context.Context, bundleUUID uuid.UUID) (*SponsorTx, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*SponsorTx, error) {
+		return r.c.GetSponsorTxByBundleUUID(ctx, bundleUUID)
+	})
+}
+
+func (r *retryClient) GetBundleByUUID(ctx context.Context, bundleUUID uuid.UUID) (*Bundle, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*Bundle, error) {
+		return r.c.GetBundleByUUID(ctx, bundleUUID)
+	})
+}
+
+func (r *retryClient) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (uint64, error) {
+		return r.c.GetTransactionCount(ctx, address, blockNrOrHash)
+	})
+}
+
+// The batch methods retry the whole batch when a chunk fails as a whole.
+
+func (r *retryClient) BatchIsSponsorable(ctx context.Context, txs []TransactionArgs) ([]BatchResult[IsSponsorableResponse], error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) ([]BatchResult[IsSponsorableResponse], error) {
+		return r.c.BatchIsSponsorable(ctx, txs)
+	})
+}
+
+func (r *retryClient) BatchGetGaslessTransactions(ctx context.Context, txHashes []common.Hash) ([]BatchResult[TransactionResponse], error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) ([]BatchResult[TransactionResponse], error) {
+		return r.c.BatchGetGaslessTransactions(ctx, txHashes)
+	})
+}
+
+func (r *retryClient) BatchGetSponsorTxByTxHash(ctx context.Context, txHashes []common.Hash) ([]BatchResult[SponsorTx], error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) ([]BatchResult[SponsorTx], error) {
+		return r.c.BatchGetSponsorTxByTxHash(ctx, txHashes)
+	})
+}
@@ -0,0 +1,67 @@
+package paymasterclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions on behalf of a single account.
+type Signer interface {
+	// Address returns the account the signer signs for
+	Address() common.Address
+	// SignTx signs the given transaction for the given chain ID
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+type privateKeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewPrivateKeySigner creates a Signer backed by an in-memory ECDSA private key.
+func NewPrivateKeySigner(key *ecdsa.PrivateKey) Signer {
+	return &privateKeySigner{key, crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// NewHexKeySigner creates a Signer from a hex encoded private key, with or without 0x prefix.
+func NewHexKeySigner(hexKey string) (Signer, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKeySigner(key), nil
+}
+
+// NewKeystoreSigner creates a Signer from an encrypted go-ethereum keystore key, as found in a keystore directory.
+func NewKeystoreSigner(keyJSON []byte, passphrase string) (Signer, error) {
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKeySigner(key.PrivateKey), nil
+}
+
+// NewKeystoreFileSigner creates a Signer from the go-ethereum keystore file at path.
+func NewKeystoreFileSigner(path string, passphrase string) (Signer, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeystoreSigner(keyJSON, passphrase)
+}
+
+func (s *privateKeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *privateKeySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
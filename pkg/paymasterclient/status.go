@@ -0,0 +1,131 @@
+package paymasterclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTransition is returned by ValidateTransition for status moves that cannot happen.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+type Status int8 // enum: new/pending/failed/confirmed/invalid
+
+const (
+	StatusNew Status = iota
+	StatusPending
+	StatusConfirmed
+	StatusFailed
+	StatusInvalid
+)
+
+var statusNames = [...]string{
+	StatusNew:       "new",
+	StatusPending:   "pending",
+	StatusConfirmed: "confirmed",
+	StatusFailed:    "failed",
+	StatusInvalid:   "invalid",
+}
+
+// ParseStatus parses a status name, as returned by String, or its number. Names are case-insensitive.
+func ParseStatus(text string) (Status, error) {
+	for s, name := range statusNames {
+		if strings.EqualFold(text, name) {
+			return Status(s), nil
+		}
+	}
+	n, err := strconv.ParseInt(text, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid status %q", text)
+	}
+	return Status(n), nil
+}
+
+// IsKnown reports whether the status is one of the statuses defined by this package.
+func (s Status) IsKnown() bool {
+	return s >= 0 && int(s) < len(statusNames)
+}
+
+// IsTerminal reports whether the status is final, i.e. it will not change anymore.
+func (s Status) IsTerminal() bool {
+	return s == StatusConfirmed || s == StatusFailed || s == StatusInvalid
+}
+
+// IsSuccess reports whether the transaction made it on chain.
+func (s Status) IsSuccess() bool {
+	return s == StatusConfirmed
+}
+
+// String returns the name of the status, or its number for statuses unknown to this package.
+func (s Status) String() string {
+	if s.IsKnown() {
+		return statusNames[s]
+	}
+	return strconv.Itoa(int(s))
+}
+
+// MarshalText encodes the status as its name. Unknown statuses are encoded as their number, so they round trip.
+// The JSON form of a status stays numeric, see MarshalJSON; text is used for map keys, flags and the like.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a status name or number, see ParseStatus.
+func (s *Status) UnmarshalText(text []byte) error {
+	status, err := ParseStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// MarshalJSON encodes the status as a JSON number, like the API does. Use String for display.
+func (s Status) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(s))), nil
+}
+
+// UnmarshalJSON decodes a status sent either as a JSON number, like the API does, or as a string holding
+// a name or number, see ParseStatus.
+func (s *Status) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		return s.UnmarshalText([]byte(text))
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var n int8
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid status %s", data)
+	}
+	*s = Status(n)
+	return nil
+}
+
+// CanTransitionTo reports whether a transaction may move from s to next. Staying in the same status
+// is always possible. Terminal statuses are never left and a pending transaction never becomes new
+// again; polling may skip intermediate statuses, so new may move directly to any terminal status.
+func (s Status) CanTransitionTo(next Status) bool {
+	if s == next {
+		return true
+	}
+	if !s.IsKnown() || !next.IsKnown() || s.IsTerminal() {
+		return false
+	}
+	return next != StatusNew
+}
+
+// ValidateTransition returns an error matching ErrInvalidTransition if a transaction cannot move from from to to.
+func ValidateTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package paymasterclient
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+var (
+	// ErrUnsupportedTxType is returned when converting transactions that TransactionArgs cannot describe
+	// losslessly, i.e. blob transactions.
+	ErrUnsupportedTxType = errors.New("unsupported transaction type")
+	// ErrIncompleteArgs is returned by ToTransaction when a field required by the transaction type is missing.
+	ErrIncompleteArgs = errors.New("incomplete transaction args")
+	// ErrInvalidArgs is returned by ToTransaction when a field does not fit the transaction type, e.g. a
+	// negative or 256-bit overflowing value in a set code transaction.
+	ErrInvalidArgs = errors.New("invalid transaction args")
+)
+
+// NewTransactionArgs describes tx, sent by from, with every field of its type. Signatures are not part
+// of TransactionArgs. The chain ID of legacy transactions is only known once they are signed, so it is
+// set for signed EIP-155 transactions only.
+func NewTransactionArgs(from common.Address, tx *types.Transaction) (TransactionArgs, error) {
+	nonce := hexutil.Uint64(tx.Nonce())
+	gas := hexutil.Uint64(tx.Gas())
+	data := hexutil.Bytes(tx.Data())
+	args := TransactionArgs{
+		To:    copyAddress(tx.To()),
+		From:  from,
+		Value: (*hexutil.Big)(tx.Value()),
+		Gas:   &gas,
+		Data:  &data,
+		Nonce: &nonce,
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		if tx.Protected() {
+			args.ChainID = (*hexutil.Big)(new(big.Int).Set(tx.ChainId()))
+		}
+	case types.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		args.ChainID = (*hexutil.Big)(new(big.Int).Set(tx.ChainId()))
+		args.AccessList = accessListOf(tx)
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		args.ChainID = (*hexutil.Big)(new(big.Int).Set(tx.ChainId()))
+		args.AccessList = accessListOf(tx)
+	case types.SetCodeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		args.ChainID = (*hexutil.Big)(new(big.Int).Set(tx.ChainId()))
+		args.AccessList = accessListOf(tx)
+		args.AuthorizationList = append([]types.SetCodeAuthorization{}, tx.SetCodeAuthorizations()...)
+	default:
+		return TransactionArgs{}, fmt.Errorf("%w: %d", ErrUnsupportedTxType, tx.Type())
+	}
+	return args, nil
+}
+
+// ToTransaction builds the unsigned transaction described by args. The type follows from the fields set:
+// an authorization list makes a set code transaction, MaxFeePerGas a dynamic fee transaction, an access
+// list an access list transaction, anything else a legacy transaction. Nonce, Gas and the fee fields of
+// the type are required, as is ChainID for typed transactions and To for set code transactions.
+func (args TransactionArgs) ToTransaction() (*types.Transaction, error) {
+	if args.Nonce == nil || args.Gas == nil {
+		return nil, fmt.Errorf("%w: nonce and gas are required", ErrIncompleteArgs)
+	}
+	var (
+		value = new(big.Int)
+		data  []byte
+	)
+	if args.Value != nil {
+		value = new(big.Int).Set(args.Value.ToInt())
+	}
+	if args.Data != nil {
+		data = common.CopyBytes(*args.Data)
+	}
+
+	switch {
+	case args.AuthorizationList != nil:
+		if args.MaxFeePerGas == nil || args.MaxPriorityFeePerGas == nil || args.ChainID == nil || args.To == nil {
+			return nil, fmt.Errorf("%w: set code transactions need maxFeePerGas, maxPriorityFeePerGas, chainId and to", ErrIncompleteArgs)
+		}
+		var (
+			inner = &types.SetCodeTx{
+				Nonce:      uint64(*args.Nonce),
+				Gas:        uint64(*args.Gas),
+				To:         *args.To,
+				Data:       data,
+				AccessList: *copyAccessList(args.AccessList),
+				AuthList:   append([]types.SetCodeAuthorization{}, args.AuthorizationList...),
+			}
+			err error
+		)
+		if inner.ChainID, err = toUint256("chainId", args.ChainID.ToInt()); err != nil {
+			return nil, err
+		}
+		if inner.GasTipCap, err = toUint256("maxPriorityFeePerGas", args.MaxPriorityFeePerGas.ToInt()); err != nil {
+			return nil, err
+		}
+		if inner.GasFeeCap, err = toUint256("maxFeePerGas", args.MaxFeePerGas.ToInt()); err != nil {
+			return nil, err
+		}
+		if inner.Value, err = toUint256("value", value); err != nil {
+			return nil, err
+		}
+		return types.NewTx(inner), nil
+
+	case args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil:
+		if args.MaxFeePerGas == nil || args.MaxPriorityFeePerGas == nil || args.ChainID == nil {
+			return nil, fmt.Errorf("%w: dynamic fee transactions need maxFeePerGas, maxPriorityFeePerGas and chainId", ErrIncompleteArgs)
+		}
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    new(big.Int).Set(args.ChainID.ToInt()),
+			Nonce:      uint64(*args.Nonce),
+			GasTipCap:  new(big.Int).Set(args.MaxPriorityFeePerGas.ToInt()),
+			GasFeeCap:  new(big.Int).Set(args.MaxFeePerGas.ToInt()),
+			Gas:        uint64(*args.Gas),
+			To:         copyAddress(args.To),
+			Value:      value,
+			Data:       data,
+			AccessList: *copyAccessList(args.AccessList),
+		}), nil
+
+	case args.AccessList != nil:
+		if args.GasPrice == nil || args.ChainID == nil {
+			return nil, fmt.Errorf("%w: access list transactions need gasPrice and chainId", ErrIncompleteArgs)
+		}
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    new(big.Int).Set(args.ChainID.ToInt()),
+			Nonce:      uint64(*args.Nonce),
+			GasPrice:   new(big.Int).Set(args.GasPrice.ToInt()),
+			Gas:        uint64(*args.Gas),
+			To:         copyAddress(args.To),
+			Value:      value,
+			Data:       data,
+			AccessList: *copyAccessList(args.AccessList),
+		}), nil
+
+	default:
+		if args.GasPrice == nil {
+			return nil, fmt.Errorf("%w: legacy transactions need gasPrice", ErrIncompleteArgs)
+		}
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(*args.Nonce),
+			GasPrice: new(big.Int).Set(args.GasPrice.ToInt()),
+			Gas:      uint64(*args.Gas),
+			To:       copyAddress(args.To),
+			Value:    value,
+			Data:     data,
+		}), nil
+	}
+}
+
+// toUint256 converts v, the field name of a set code transaction, to a uint256.
+func toUint256(name string, v *big.Int) (*uint256.Int, error) {
+	if v.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %s is negative", ErrInvalidArgs, name)
+	}
+	u, overflow := uint256.FromBig(v)
+	if overflow {
+		return nil, fmt.Errorf("%w: %s overflows 256 bits", ErrInvalidArgs, name)
+	}
+	return u, nil
+}
+
+func copyAddress(address *common.Address) *common.Address {
+	if address == nil {
+		return nil
+	}
+	c := *address
+	return &c
+}
+
+// accessListOf returns a copy of the access list of tx, empty rather than nil.
+func accessListOf(tx *types.Transaction) *types.AccessList {
+	accessList := tx.AccessList()
+	return copyAccessList(&accessList)
+}
+
+// copyAccessList returns a deep copy of accessList, empty rather than nil.
+func copyAccessList(accessList *types.AccessList) *types.AccessList {
+	c := types.AccessList{}
+	if accessList == nil {
+		return &c
+	}
+	for _, tuple := range *accessList {
+		c = append(c, types.AccessTuple{
+			Address:     tuple.Address,
+			StorageKeys: append([]common.Hash{}, tuple.StorageKeys...),
+		})
+	}
+	return &c
+}
@@ -3,20 +3,32 @@ package paymasterclient
 import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/gofrs/uuid"
 
 	"github.com/node-real/megafuel-go-sdk/pkg/types"
 )
 
+// TransactionArgs describes a transaction for IsSponsorable. Use NewTransactionArgs to describe an
+// existing transaction and ToTransaction to build one, so that the transaction checked is the one signed.
 type TransactionArgs struct {
 	To    *common.Address `json:"to"`
 	From  common.Address  `json:"from"`
 	Value *hexutil.Big    `json:"value"`
 	Gas   *hexutil.Uint64 `json:"gas"`
 	Data  *hexutil.Bytes  `json:"data"`
+
+	Nonce                *hexutil.Uint64                 `json:"nonce,omitempty"`
+	GasPrice             *hexutil.Big                    `json:"gasPrice,omitempty"`             // GasPrice is set for legacy and access list transactions.
+	MaxFeePerGas         *hexutil.Big                    `json:"maxFeePerGas,omitempty"`         // MaxFeePerGas is set for dynamic fee and set code transactions.
+	MaxPriorityFeePerGas *hexutil.Big                    `json:"maxPriorityFeePerGas,omitempty"` // MaxPriorityFeePerGas is set for dynamic fee and set code transactions.
+	AccessList           *ethtypes.AccessList            `json:"accessList,omitempty"`           // AccessList is set for all typed transactions, even if empty.
+	ChainID              *hexutil.Big                    `json:"chainId,omitempty"`
+	AuthorizationList    []ethtypes.SetCodeAuthorization `json:"authorizationList,omitempty"` // AuthorizationList is set for EIP-7702 set code transactions.
 }
 
 // TransactionOptions defines the options for the SendRawTransaction method.
+// The options only apply to the call they are passed to.
 type TransactionOptions struct {
 	// UserAgent is an optional field to set a custom User-Agent header for the request.
 	UserAgent string
@@ -29,16 +41,6 @@ type IsSponsorableResponse struct {
 	SponsorWebsite string `json:"sponsorWebsite,omitempty"` // SponsorWebsite is an optional field, string value, shows the website of the policy sponsor.
 }
 
-type Status int8 // enum: new/pending/failed/confirmed/invalid
-
-const (
-	StatusNew Status = iota
-	StatusPending
-	StatusConfirmed
-	StatusFailed
-	StatusInvalid
-)
-
 type TransactionResponse struct {
 	TxHash          common.Hash     `json:"txHash"`
 	BundleUUID      uuid.UUID       `json:"bundleUuid"`
@@ -0,0 +1,151 @@
+package paymasterclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+)
+
+const (
+	defaultWaitPollInterval    = time.Second
+	defaultWaitMaxPollInterval = 10 * time.Second
+	defaultWaitMultiplier      = 1.5
+)
+
+// WaitOptions defines the options for WaitForGaslessTransaction.
+type WaitOptions struct {
+	// PollInterval is the delay before the second poll. Default value is 1s.
+	PollInterval time.Duration
+	// MaxPollInterval caps the delay between two polls. Default value is 10s.
+	MaxPollInterval time.Duration
+	// Multiplier grows the delay after every poll. Values below 1 are treated as 1. Default value is 1.5.
+	Multiplier float64
+	// OnStatusChange is an optional callback invoked every time the observed status changes,
+	// including the first time the transaction is observed.
+	OnStatusChange func(StatusChange)
+}
+
+// StatusChange describes a status transition observed by WaitForGaslessTransaction.
+type StatusChange struct {
+	TxHash      common.Hash
+	First       bool   // First is true for the first observation, From is meaningless in that case.
+	From        Status // From is the previously observed status.
+	To          Status // To is the newly observed status.
+	Transaction *TransactionResponse
+}
+
+// Validate returns an error matching ErrInvalidTransition if the change cannot happen, which points at bad data from the API.
+func (c StatusChange) Validate() error {
+	if c.First {
+		return nil
+	}
+	return ValidateTransition(c.From, c.To)
+}
+
+// WaitResult is the final state of a gasless transaction.
+type WaitResult struct {
+	Transaction *TransactionResponse
+	Bundle      *Bundle // Bundle is nil if the transaction was never put into a bundle.
+}
+
+// WaitForGaslessTransaction polls GetGaslessTransactionByHash until the transaction reaches a terminal
+// status, then fetches its bundle with GetBundleByUUID. Not found and retryable lookup errors are treated
+// as transient, e.g. the transaction has not been indexed yet, and polling continues until ctx is done.
+// Other lookup errors, e.g. mferrors.ErrUnauthorized, are returned right away.
+func WaitForGaslessTransaction(ctx context.Context, client Client, txHash common.Hash, opts *WaitOptions) (*WaitResult, error) {
+	var o WaitOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultWaitPollInterval
+	}
+	if o.MaxPollInterval <= 0 {
+		o.MaxPollInterval = defaultWaitMaxPollInterval
+	}
+	if o.Multiplier == 0 {
+		o.Multiplier = defaultWaitMultiplier
+	} else if o.Multiplier < 1 {
+		o.Multiplier = 1
+	}
+
+	var (
+		interval = o.PollInterval
+		observed bool
+		last     Status
+		lastErr  error
+		result   WaitResult
+	)
+	for {
+		if result.Transaction == nil {
+			tx, err := client.GetGaslessTransactionByHash(ctx, txHash)
+			if err != nil {
+				if !isTransientLookupError(ctx, err) {
+					return nil, err
+				}
+				lastErr = lookupError(ctx, err, lastErr)
+			} else {
+				if !observed || tx.Status != last {
+					if o.OnStatusChange != nil {
+						o.OnStatusChange(StatusChange{TxHash: txHash, First: !observed, From: last, To: tx.Status, Transaction: tx})
+					}
+					observed, last = true, tx.Status
+				}
+				if tx.Status.IsTerminal() {
+					result.Transaction = tx
+				}
+			}
+		}
+
+		if result.Transaction != nil {
+			if result.Transaction.BundleUUID == uuid.Nil {
+				return &result, nil
+			}
+			bundle, err := client.GetBundleByUUID(ctx, result.Transaction.BundleUUID)
+			if err == nil {
+				result.Bundle = bundle
+				return &result, nil
+			}
+			if !isTransientLookupError(ctx, err) {
+				return nil, err
+			}
+			lastErr = lookupError(ctx, err, lastErr)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * o.Multiplier)
+		if interval > o.MaxPollInterval {
+			interval = o.MaxPollInterval
+		}
+	}
+}
+
+// isTransientLookupError reports whether a lookup may succeed later. Errors due to ctx being done are
+// reported by the wait loop.
+func isTransientLookupError(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, mferrors.ErrNotFound) || mferrors.IsRetryable(err)
+}
+
+// lookupError returns the error to report if the wait ends, keeping the last one if err is due to ctx being done.
+func lookupError(ctx context.Context, err, lastErr error) error {
+	if ctx.Err() != nil && lastErr != nil {
+		return lastErr
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+// Package retry implements exponential backoff with jitter for MegaFuel JSON-RPC calls.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+)
+
+// Policy configures the backoff between attempts.
+type Policy struct {
+	// InitialInterval is the delay after the first failed attempt. Default value is 200ms.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between two attempts. Default value is 5s.
+	MaxInterval time.Duration
+	// Multiplier grows the delay after every failed attempt. Default value is 2.
+	Multiplier float64
+	// RandomizationFactor spreads every delay randomly within [delay*(1-f), delay*(1+f)]. Default value is 0.5.
+	RandomizationFactor float64
+	// MaxElapsedTime stops retrying once it has passed since the first attempt. Default value is 30s.
+	MaxElapsedTime time.Duration
+	// MaxAttempts stops retrying after that many attempts, zero means no limit.
+	MaxAttempts int
+}
+
+// DefaultPolicy returns the policy used when a nil policy is given.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		InitialInterval:     200 * time.Millisecond,
+		MaxInterval:         5 * time.Second,
+		Multiplier:          2,
+		RandomizationFactor: 0.5,
+		MaxElapsedTime:      30 * time.Second,
+	}
+}
+
+// Do calls fn until it succeeds, returns an error rejected by retryable, ctx is done or the policy
+// gives up. It returns the last error of fn. When a failed attempt carried a Retry-After hint,
+// see Transport, the next attempt waits at least that long.
+func Do(ctx context.Context, policy *Policy, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	_, err := Call(ctx, policy, retryable, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// Call is Do for functions returning a value.
+func Call[T any](ctx context.Context, policy *Policy, retryable func(error) bool, fn func(ctx context.Context) (T, error)) (T, error) {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	var (
+		start    = time.Now()
+		interval = policy.InitialInterval
+	)
+	for attempt := 1; ; attempt++ {
+		hint := new(retryAfterHint)
+		result, err := fn(context.WithValue(ctx, retryAfterKey{}, hint))
+		if err == nil || !retryable(err) || ctx.Err() != nil {
+			return result, err
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return result, err
+		}
+
+		delay := randomize(interval, policy.RandomizationFactor)
+		if retryAfter := hint.get(); retryAfter > delay {
+			delay = retryAfter
+		}
+		if policy.MaxElapsedTime > 0 && time.Since(start)+delay > policy.MaxElapsedTime {
+			return result, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * policy.Multiplier)
+		if policy.MaxInterval > 0 && interval > policy.MaxInterval {
+			interval = policy.MaxInterval
+		}
+	}
+}
+
+func randomize(interval time.Duration, factor float64) time.Duration {
+	if factor <= 0 {
+		return interval
+	}
+	delta := factor * float64(interval)
+	return time.Duration(float64(interval) - delta + rand.Float64()*2*delta)
+}
+
+// IsRetryable reports whether a call that is safe to repeat, e.g. a read, should be retried after err.
+func IsRetryable(err error) bool {
+	return mferrors.IsRetryable(err)
+}
+
+// IsNotSent reports whether err shows that the call never reached the endpoint, or was explicitly
+// rejected before being processed, so that even a call that is not safe to repeat can be retried.
+func IsNotSent(err error) bool {
+	if errors.Is(err, mferrors.ErrRateLimited) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type retryAfterKey struct{}
+
+// retryAfterHint carries the Retry-After delay of a failed attempt from Transport back to Call.
+type retryAfterHint struct {
+	mu    sync.Mutex
+	delay time.Duration
+}
+
+func (h *retryAfterHint) set(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.delay = d
+}
+
+func (h *retryAfterHint) get() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.delay
+}
+
+// Transport is an http.RoundTripper reporting the Retry-After header of 429 and 503 responses to Call.
+// paymasterclient and sponsorclient install it by default; wrap the transport of a custom http.Client
+// passed with rpc.WithHTTPClient to keep Retry-After support.
+type Transport struct {
+	// Base is the underlying RoundTripper. Nil means http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// NewTransport returns a Transport wrapping base.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// NewHTTPClient returns an http.Client using a Transport over http.DefaultTransport.
+func NewHTTPClient() *http.Client {
+	return &http.Client{Transport: NewTransport(nil)}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if hint, ok := req.Context().Value(retryAfterKey{}).(*retryAfterHint); ok {
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				hint.set(d)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// parseRetryAfter parses a Retry-After value given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
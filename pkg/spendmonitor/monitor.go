@@ -0,0 +1,371 @@
+// Package spendmonitor watches the spend data of MegaFuel policies and their users and sends alerts
+// when configured thresholds are crossed.
+//
+// A Monitor polls sponsorclient.GetPolicySpendData and GetUserSpendData on a schedule, keeps a rolling
+// history of the samples and evaluates its thresholds after every poll. An alert is sent once when a value
+// reaches its threshold and a recovery once it drops back below, e.g. when the daily counters reset. An alert
+// that no notifier could deliver is sent again on the next poll.
+package spendmonitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+const (
+	defaultInterval    = time.Minute
+	defaultPollTimeout = 10 * time.Second
+	defaultHistorySize = 60
+	defaultDailyWindow = 24 * time.Hour
+)
+
+// Metric is a spend value a Threshold applies to.
+type Metric string
+
+const (
+	// PolicyCost is the total cost of a policy, PolicySpendData.Cost.
+	PolicyCost Metric = "PolicyCost"
+	// PolicyDailyCost is the cost of a policy over the last Options.DailyWindow, the increase of
+	// PolicySpendData.Cost since the newest sample of the history that is at least that old. Until the
+	// history spans the window, it is the increase since the oldest sample.
+	PolicyDailyCost Metric = "PolicyDailyCost"
+	// UserGasCost is the total gas cost of a user, UserSpendData.GasCost.
+	UserGasCost Metric = "UserGasCost"
+	// UserGasCostCurDay is the gas cost of a user on the current day, UserSpendData.GasCostCurDay.
+	UserGasCostCurDay Metric = "UserGasCostCurDay"
+	// UserTxCountCurDay is the number of transactions of a user on the current day, UserSpendData.TxCountCurDay.
+	UserTxCountCurDay Metric = "UserTxCountCurDay"
+)
+
+func (m Metric) isUser() bool {
+	return m != PolicyCost && m != PolicyDailyCost
+}
+
+// Watch selects a policy, and optionally some of its users, to poll.
+type Watch struct {
+	PolicyUUID uuid.UUID
+	Users      []common.Address
+}
+
+// Threshold fires an alert when a metric reaches Limit.
+type Threshold struct {
+	Metric Metric `json:"metric"`
+	// PolicyUUID restricts the threshold to a policy, uuid.Nil applies it to every watched policy.
+	PolicyUUID uuid.UUID `json:"policyUuid"`
+	// User restricts a user metric threshold to a user, the zero address applies it to every watched user.
+	User  common.Address `json:"user"`
+	Limit *big.Int       `json:"limit"`
+}
+
+// Sample is the spend data of a watched policy and its users fetched by one poll.
+type Sample struct {
+	Time   time.Time
+	Policy *sponsorclient.PolicySpendData // Policy is nil if the policy has no spend data yet.
+	// Users holds the spend data of the watched users, users without spend data are missing.
+	Users map[common.Address]*sponsorclient.UserSpendData
+}
+
+// Options defines the options for NewMonitor.
+type Options struct {
+	Watches    []Watch
+	Thresholds []Threshold
+	Notifiers  []Notifier
+	// Interval is the delay between two background polls. Default value is 1m, a negative value disables
+	// background polling, Poll is then called by the caller.
+	Interval time.Duration
+	// PollTimeout bounds a background poll. Default value is 10s.
+	PollTimeout time.Duration
+	// HistorySize is the number of samples kept per policy. Default value is the number of background polls
+	// in DailyWindow plus one, at least 60.
+	HistorySize int
+	// DailyWindow is the span of PolicyDailyCost. Default value is 24h.
+	DailyWindow time.Duration
+	// OnError is an optional callback receiving the errors of background polls.
+	OnError func(error)
+}
+
+type alertKey struct {
+	threshold int
+	policy    uuid.UUID
+	user      common.Address
+}
+
+// pendingAlert is an alert whose state change is recorded once it has been delivered.
+type pendingAlert struct {
+	key     alertKey
+	reached bool
+	alert   Alert
+}
+
+// Monitor polls spend data and sends alerts, see the package documentation.
+type Monitor struct {
+	client sponsorclient.Client
+	opts   Options
+
+	pollMu sync.Mutex // pollMu serializes polls.
+
+	mu      sync.Mutex
+	history map[uuid.UUID][]Sample
+	firing  map[alertKey]bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMonitor creates a Monitor over client. Unless disabled, it polls in the background until Close,
+// starting right away.
+func NewMonitor(client sponsorclient.Client, opts *Options) *Monitor {
+	m := &Monitor{
+		client:  client,
+		history: make(map[uuid.UUID][]Sample),
+		firing:  make(map[alertKey]bool),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.Interval == 0 {
+		m.opts.Interval = defaultInterval
+	}
+	if m.opts.PollTimeout <= 0 {
+		m.opts.PollTimeout = defaultPollTimeout
+	}
+	if m.opts.DailyWindow <= 0 {
+		m.opts.DailyWindow = defaultDailyWindow
+	}
+	if m.opts.HistorySize <= 0 {
+		m.opts.HistorySize = defaultHistorySize
+		if m.opts.Interval > 0 {
+			m.opts.HistorySize = max(m.opts.HistorySize, int(m.opts.DailyWindow/m.opts.Interval)+1)
+		}
+	}
+
+	if m.opts.Interval > 0 {
+		go m.loop()
+	} else {
+		close(m.done)
+	}
+	return m
+}
+
+// Close stops the background polls.
+func (m *Monitor) Close() {
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	<-m.done
+}
+
+func (m *Monitor) loop() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), m.opts.PollTimeout)
+		err := m.Poll(ctx)
+		cancel()
+		if err != nil && m.opts.OnError != nil {
+			m.opts.OnError(err)
+		}
+
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches the spend data of every watch, records the samples and evaluates the thresholds, sending
+// the resulting alerts. Spend data that cannot be fetched is left out of the samples and its thresholds
+// keep their state; the errors are returned together with those of the notifiers. A threshold changes
+// state once at least one notifier delivered its alert, otherwise the alert is sent again on the next poll.
+func (m *Monitor) Poll(ctx context.Context) error {
+	m.pollMu.Lock()
+	defer m.pollMu.Unlock()
+
+	var errs []error
+	samples := make(map[uuid.UUID]Sample, len(m.opts.Watches))
+	for _, watch := range m.opts.Watches {
+		sample := Sample{Time: time.Now(), Users: make(map[common.Address]*sponsorclient.UserSpendData)}
+		policy, err := m.client.GetPolicySpendData(ctx, watch.PolicyUUID)
+		switch {
+		case err == nil:
+			sample.Policy = policy
+		case !errors.Is(err, mferrors.ErrNotFound):
+			errs = append(errs, fmt.Errorf("policy %s: %w", watch.PolicyUUID, err))
+		}
+		for _, user := range watch.Users {
+			data, err := m.client.GetUserSpendData(ctx, user, watch.PolicyUUID)
+			switch {
+			case err == nil:
+				sample.Users[user] = data
+			case !errors.Is(err, mferrors.ErrNotFound):
+				errs = append(errs, fmt.Errorf("user %s of policy %s: %w", user.Hex(), watch.PolicyUUID, err))
+			}
+		}
+		samples[watch.PolicyUUID] = sample
+	}
+
+	m.mu.Lock()
+	for policyUUID, sample := range samples {
+		history := append(m.history[policyUUID], sample)
+		if len(history) > m.opts.HistorySize {
+			history = append([]Sample(nil), history[len(history)-m.opts.HistorySize:]...)
+		}
+		m.history[policyUUID] = history
+	}
+	alerts := m.evaluate(samples)
+	m.mu.Unlock()
+
+	delivered := make([]pendingAlert, 0, len(alerts))
+	for _, pending := range alerts {
+		ok := len(m.opts.Notifiers) == 0
+		for _, notifier := range m.opts.Notifiers {
+			if err := notifier.Notify(ctx, pending.alert); err != nil {
+				errs = append(errs, fmt.Errorf("notify %s: %w", pending.alert, err))
+			} else {
+				ok = true
+			}
+		}
+		if ok {
+			delivered = append(delivered, pending)
+		}
+	}
+
+	m.mu.Lock()
+	for _, pending := range delivered {
+		m.firing[pending.key] = pending.reached
+	}
+	m.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// evaluate returns the alerts of the thresholds whose state changes with samples, without recording the
+// new states. It is called with mu held, after samples have been added to the history.
+func (m *Monitor) evaluate(samples map[uuid.UUID]Sample) []pendingAlert {
+	var alerts []pendingAlert
+	check := func(i int, threshold Threshold, policyUUID uuid.UUID, user common.Address, value *big.Int, at time.Time) {
+		if value == nil || threshold.Limit == nil {
+			return
+		}
+		key := alertKey{i, policyUUID, user}
+		reached := value.Cmp(threshold.Limit) >= 0
+		if reached == m.firing[key] {
+			return
+		}
+		kind := AlertFired
+		if !reached {
+			kind = AlertRecovered
+		}
+		alerts = append(alerts, pendingAlert{key: key, reached: reached, alert: Alert{
+			Kind:       kind,
+			Threshold:  threshold,
+			PolicyUUID: policyUUID,
+			User:       user,
+			Value:      new(big.Int).Set(value),
+			Time:       at,
+		}})
+	}
+
+	for i, threshold := range m.opts.Thresholds {
+		for _, watch := range m.opts.Watches {
+			if threshold.PolicyUUID != uuid.Nil && threshold.PolicyUUID != watch.PolicyUUID {
+				continue
+			}
+			sample, ok := samples[watch.PolicyUUID]
+			if !ok {
+				continue
+			}
+			if !threshold.Metric.isUser() {
+				if sample.Policy != nil {
+					check(i, threshold, watch.PolicyUUID, common.Address{}, m.policyValue(threshold.Metric, watch.PolicyUUID, sample), sample.Time)
+				}
+				continue
+			}
+			for _, user := range watch.Users {
+				if threshold.User != (common.Address{}) && threshold.User != user {
+					continue
+				}
+				if data, ok := sample.Users[user]; ok {
+					check(i, threshold, watch.PolicyUUID, user, userValue(threshold.Metric, data), sample.Time)
+				}
+			}
+		}
+	}
+	return alerts
+}
+
+// policyValue returns the value of a policy metric for sample, the newest sample of the policy history.
+func (m *Monitor) policyValue(metric Metric, policyUUID uuid.UUID, sample Sample) *big.Int {
+	cost := sample.Policy.Cost.Raw()
+	if metric != PolicyDailyCost || cost == nil {
+		return cost
+	}
+	var base *big.Int
+	since := sample.Time.Add(-m.opts.DailyWindow)
+	for _, old := range m.history[policyUUID] {
+		if old.Policy == nil || old.Policy.Cost == nil {
+			continue
+		}
+		if base == nil || !old.Time.After(since) {
+			base = old.Policy.Cost.Raw()
+		}
+		if old.Time.After(since) {
+			break
+		}
+	}
+	daily := new(big.Int).Sub(cost, base)
+	if daily.Sign() < 0 {
+		// The cost went down, e.g. the policy was reset; count from zero.
+		return cost
+	}
+	return daily
+}
+
+func userValue(metric Metric, data *sponsorclient.UserSpendData) *big.Int {
+	switch metric {
+	case UserGasCost:
+		return data.GasCost.Raw()
+	case UserGasCostCurDay:
+		return data.GasCostCurDay.Raw()
+	case UserTxCountCurDay:
+		return new(big.Int).SetUint64(data.TxCountCurDay)
+	}
+	return nil
+}
+
+// History returns the samples of a policy kept so far, oldest first.
+func (m *Monitor) History(policyUUID uuid.UUID) []Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Sample(nil), m.history[policyUUID]...)
+}
+
+// Firing returns the thresholds currently reached, as the alerts that fired them would report them
+// without value and time.
+func (m *Monitor) Firing() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var firing []Alert
+	for key, reached := range m.firing {
+		if reached {
+			firing = append(firing, Alert{Kind: AlertFired, Threshold: m.opts.Thresholds[key.threshold], PolicyUUID: key.policy, User: key.user})
+		}
+	}
+	return firing
+}
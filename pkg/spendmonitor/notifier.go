@@ -0,0 +1,115 @@
+package spendmonitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+)
+
+// AlertKind tells whether an alert fires or recovers.
+type AlertKind string
+
+const (
+	// AlertFired is sent when a value reaches its threshold.
+	AlertFired AlertKind = "fired"
+	// AlertRecovered is sent when a value drops back below its threshold.
+	AlertRecovered AlertKind = "recovered"
+)
+
+// Alert is a change of state of a threshold for a policy, or a user of a policy for user metrics.
+type Alert struct {
+	Kind       AlertKind      `json:"kind"`
+	Threshold  Threshold      `json:"threshold"`
+	PolicyUUID uuid.UUID      `json:"policyUuid"`
+	User       common.Address `json:"user"` // User is the zero address for policy metrics.
+	Value      *big.Int       `json:"value"`
+	Time       time.Time      `json:"time"`
+}
+
+func (a Alert) String() string {
+	subject := "policy " + a.PolicyUUID.String()
+	if a.Threshold.Metric.isUser() {
+		subject = fmt.Sprintf("user %s of %s", a.User.Hex(), subject)
+	}
+	return fmt.Sprintf("%s %s: %s %s, limit %s", a.Threshold.Metric, a.Kind, subject, a.Value, a.Threshold.Limit)
+}
+
+// Notifier delivers alerts.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NotifierFunc is a function used as a Notifier.
+type NotifierFunc func(ctx context.Context, alert Alert) error
+
+// Notify calls f.
+func (f NotifierFunc) Notify(ctx context.Context, alert Alert) error {
+	return f(ctx, alert)
+}
+
+// NewWriterNotifier returns a Notifier writing every alert as a line to w, e.g. os.Stdout.
+func NewWriterNotifier(w io.Writer) Notifier {
+	return NotifierFunc(func(ctx context.Context, alert Alert) error {
+		_, err := fmt.Fprintf(w, "%s %s\n", alert.Time.Format(time.RFC3339), alert)
+		return err
+	})
+}
+
+// NewChannelNotifier returns a Notifier sending every alert on ch. It blocks until the alert is received
+// or ctx is done.
+func NewChannelNotifier(ch chan<- Alert) Notifier {
+	return NotifierFunc(func(ctx context.Context, alert Alert) error {
+		select {
+		case ch <- alert:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// WebhookNotifier POSTs every alert as JSON to a URL.
+type WebhookNotifier struct {
+	URL    string
+	Header http.Header  // Header is added to every request, e.g. for authentication.
+	Client *http.Client // Client sends the requests, http.DefaultClient if nil.
+}
+
+// Notify POSTs alert to n.URL and fails unless the response status is 2xx.
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range n.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
@@ -2,21 +2,33 @@ package sponsorclient
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/apikey"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
+	"github.com/node-real/megafuel-go-sdk/pkg/retry"
 )
 
 type Client interface {
-	// AddToWhitelist adds a list of values to the whitelist of a policy
+	// AddToWhitelist adds a list of values to the whitelist of a policy. The values are validated and
+	// normalized first, see WhitelistType.NormalizeValue; invalid values fail the call with a *ValidationError.
 	AddToWhitelist(ctx context.Context, args WhiteListArgs) (bool, error)
-	// RmFromWhitelist removes a list of values from the whitelist of a policy
+	// RmFromWhitelist removes a list of values from the whitelist of a policy, validated like AddToWhitelist
 	RmFromWhitelist(ctx context.Context, args WhiteListArgs) (bool, error)
 	// EmptyWhitelist clear the whitelist of a policy
 	EmptyWhitelist(ctx context.Context, args EmptyWhiteListArgs) (bool, error)
 	// GetWhitelist returns the whitelist of a policy
+	//
+	// Deprecated: use GetWhitelistPage, or NewWhitelistIterator to walk the whole whitelist.
 	GetWhitelist(ctx context.Context, args GetWhitelistArgs) (interface{}, error)
+	// GetWhitelistPage returns a page of the whitelist of a policy
+	GetWhitelistPage(ctx context.Context, args GetWhitelistArgs) (*WhitelistPage, error)
 
 	// GetUserSpendData returns the user spend data on a policy
 	GetUserSpendData(ctx context.Context, fromAddress common.Address, policyUUID uuid.UUID) (*UserSpendData, error)
@@ -29,16 +41,42 @@ type client struct {
 }
 
 func New(ctx context.Context, url string, options ...rpc.ClientOption) (Client, error) {
-	c, err := rpc.DialOptions(ctx, url, options...)
+	c, err := rpc.DialOptions(ctx, url, withDefaultOptions(options)...)
 	if err != nil {
-		return nil, err
+		return nil, apikey.RedactError(err)
 	}
 	return &client{c}, nil
 }
 
+// NewWithCredential creates a new Client taking the API key from cred for every request. urlTemplate has
+// the networks.APIKeyPlaceholder slot of the key, e.g. https://open-platform-ap.nodereal.io/{apikey}/megafuel.
+// A custom http.Client passed in options must wrap its transport with apikey.NewTransport.
+func NewWithCredential(ctx context.Context, urlTemplate string, cred apikey.Credential, options ...rpc.ClientOption) (Client, error) {
+	if !apikey.HasPlaceholder(urlTemplate) {
+		return nil, fmt.Errorf("url template %s has no %s slot", apikey.URL(urlTemplate), networks.APIKeyPlaceholder)
+	}
+	httpClient := &http.Client{Transport: apikey.NewTransport(cred, retry.NewTransport(nil))}
+	return New(ctx, urlTemplate, append([]rpc.ClientOption{rpc.WithHTTPClient(httpClient)}, options...)...)
+}
+
+// NewForNetwork creates a new Client for the sponsor endpoint of network with the given API key.
+func NewForNetwork(ctx context.Context, network networks.Network, apiKey string, options ...rpc.ClientOption) (Client, error) {
+	return NewForNetworkWithCredential(ctx, network, apikey.Static(apiKey), options...)
+}
+
+// NewForNetworkWithCredential creates a new Client for the sponsor endpoint of network, taking the API key
+// from cred for every request, see NewWithCredential.
+func NewForNetworkWithCredential(ctx context.Context, network networks.Network, cred apikey.Credential, options ...rpc.ClientOption) (Client, error) {
+	return NewWithCredential(ctx, network.SponsorURLTemplate, cred, options...)
+}
+
 func (c *client) AddToWhitelist(ctx context.Context, args WhiteListArgs) (bool, error) {
+	args, err := normalizeArgs("pm_addToWhitelist", args)
+	if err != nil {
+		return false, err
+	}
 	var result bool
-	err := c.c.CallContext(ctx, &result, "pm_addToWhitelist", args)
+	err = c.call(ctx, &result, "pm_addToWhitelist", args)
 	if err != nil {
 		return false, err
 	}
@@ -46,8 +84,12 @@ func (c *client) AddToWhitelist(ctx context.Context, args WhiteListArgs) (bool,
 }
 
 func (c *client) RmFromWhitelist(ctx context.Context, args WhiteListArgs) (bool, error) {
+	args, err := normalizeArgs("pm_rmFromWhitelist", args)
+	if err != nil {
+		return false, err
+	}
 	var result bool
-	err := c.c.CallContext(ctx, &result, "pm_rmFromWhitelist", args)
+	err = c.call(ctx, &result, "pm_rmFromWhitelist", args)
 	if err != nil {
 		return false, err
 	}
@@ -56,7 +98,7 @@ func (c *client) RmFromWhitelist(ctx context.Context, args WhiteListArgs) (bool,
 
 func (c *client) EmptyWhitelist(ctx context.Context, args EmptyWhiteListArgs) (bool, error) {
 	var result bool
-	err := c.c.CallContext(ctx, &result, "pm_emptyWhitelist", args)
+	err := c.call(ctx, &result, "pm_emptyWhitelist", args)
 	if err != nil {
 		return false, err
 	}
@@ -65,16 +107,25 @@ func (c *client) EmptyWhitelist(ctx context.Context, args EmptyWhiteListArgs) (b
 
 func (c *client) GetWhitelist(ctx context.Context, args GetWhitelistArgs) (interface{}, error) {
 	var result interface{}
-	err := c.c.CallContext(ctx, &result, "pm_getWhitelist", args)
+	err := c.call(ctx, &result, "pm_getWhitelist", args)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
+func (c *client) GetWhitelistPage(ctx context.Context, args GetWhitelistArgs) (*WhitelistPage, error) {
+	var result WhitelistPage
+	err := c.call(ctx, &result, "pm_getWhitelist", args)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 func (c *client) GetUserSpendData(ctx context.Context, fromAddress common.Address, policyUUID uuid.UUID) (*UserSpendData, error) {
 	var result UserSpendData
-	err := c.c.CallContext(ctx, &result, "pm_getUserSpendData", fromAddress, policyUUID)
+	err := c.call(ctx, &result, "pm_getUserSpendData", fromAddress, policyUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -83,9 +134,22 @@ func (c *client) GetUserSpendData(ctx context.Context, fromAddress common.Addres
 
 func (c *client) GetPolicySpendData(ctx context.Context, policyUUID uuid.UUID) (*PolicySpendData, error) {
 	var result PolicySpendData
-	err := c.c.CallContext(ctx, &result, "pm_getPolicySpendData", policyUUID)
+	err := c.call(ctx, &result, "pm_getPolicySpendData", policyUUID)
 	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
+
+// call invokes method with a fresh request ID and classifies any failure into an *mferrors.Error.
+func (c *client) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	requestID := mferrors.NewRequestID()
+	ctx = rpc.NewContextWithHeaders(ctx, http.Header{mferrors.RequestIDHeader: {requestID}})
+	return mferrors.Wrap(method, requestID, c.c.CallContext(ctx, result, method, args...))
+}
+
+// withDefaultOptions puts the default client options in front of options, so that options can override them.
+// The default HTTP client reports Retry-After hints to the retry layer.
+func withDefaultOptions(options []rpc.ClientOption) []rpc.ClientOption {
+	return append([]rpc.ClientOption{rpc.WithHTTPClient(retry.NewHTTPClient())}, options...)
+}
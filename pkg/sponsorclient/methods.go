@@ -0,0 +1,176 @@
+package sponsorclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gofrs/uuid"
+)
+
+// MethodSet maps contract methods to the selectors of the ContractMethodSigWhitelist and back.
+// The methods of an abigen binding are added with the ABI returned by the GetAbi method of its metadata.
+type MethodSet struct {
+	byName     map[string][]abi.Method // byName holds the methods by name, overloads included.
+	bySelector map[string]abi.Method
+}
+
+// NewMethodSet returns a MethodSet of the methods of the given ABIs.
+func NewMethodSet(abis ...*abi.ABI) *MethodSet {
+	s := &MethodSet{byName: make(map[string][]abi.Method), bySelector: make(map[string]abi.Method)}
+	for _, a := range abis {
+		if a == nil {
+			continue
+		}
+		for _, method := range a.Methods {
+			s.add(method)
+		}
+	}
+	return s
+}
+
+// ParseMethodSet returns a MethodSet of the methods of the contract ABI abiJSON.
+func ParseMethodSet(abiJSON string) (*MethodSet, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract abi: %w", err)
+	}
+	return NewMethodSet(&parsed), nil
+}
+
+// AddSignatures adds the methods with the given signatures, see ParseMethodSignature.
+func (s *MethodSet) AddSignatures(signatures ...string) error {
+	for _, signature := range signatures {
+		method, err := ParseMethodSignature(signature)
+		if err != nil {
+			return err
+		}
+		s.add(method)
+	}
+	return nil
+}
+
+func (s *MethodSet) add(method abi.Method) {
+	selector := hexutil.Encode(method.ID)
+	if _, ok := s.bySelector[selector]; ok {
+		return
+	}
+	s.bySelector[selector] = method
+	s.byName[method.RawName] = append(s.byName[method.RawName], method)
+}
+
+// Selectors returns the selectors of methods, in order. A method is given by its name, its full signature
+// such as "transfer(address,uint256)", or its selector. Names must be in the set and must not be
+// overloaded; signatures and selectors need not be in the set, which may be nil.
+func (s *MethodSet) Selectors(methods ...string) ([]string, error) {
+	selectors := make([]string, len(methods))
+	for i, method := range methods {
+		selector, err := s.selector(strings.TrimSpace(method))
+		if err != nil {
+			return nil, err
+		}
+		selectors[i] = selector
+	}
+	return selectors, nil
+}
+
+func (s *MethodSet) selector(method string) (string, error) {
+	switch {
+	case has0xPrefix(method):
+		return ContractMethodSigWhitelist.NormalizeValue(method)
+	case strings.Contains(method, "("):
+		parsed, err := ParseMethodSignature(method)
+		if err != nil {
+			return "", err
+		}
+		return hexutil.Encode(parsed.ID), nil
+	}
+	if s == nil {
+		return "", fmt.Errorf("unknown method %q", method)
+	}
+	switch overloads := s.byName[method]; len(overloads) {
+	case 0:
+		return "", fmt.Errorf("unknown method %q", method)
+	case 1:
+		return hexutil.Encode(overloads[0].ID), nil
+	default:
+		sigs := make([]string, len(overloads))
+		for i, m := range overloads {
+			sigs[i] = m.Sig
+		}
+		return "", fmt.Errorf("method %q is overloaded, use one of the signatures %s", method, strings.Join(sigs, ", "))
+	}
+}
+
+// Signature returns the signature of the method with the given selector, if it is in the set.
+func (s *MethodSet) Signature(selector string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	selector, err := ContractMethodSigWhitelist.NormalizeValue(selector)
+	if err != nil {
+		return "", false
+	}
+	method, ok := s.bySelector[selector]
+	return method.Sig, ok
+}
+
+// ParseMethodSignature parses a method signature such as "transfer(address, uint256)" into a method
+// with its canonical signature and selector. Argument types must be canonical, e.g. uint256 and not uint.
+func ParseMethodSignature(signature string) (abi.Method, error) {
+	selector, err := abi.ParseSelector(strings.ReplaceAll(signature, " ", ""))
+	if err != nil {
+		return abi.Method{}, err
+	}
+	data, err := json.Marshal([]abi.SelectorMarshaling{selector})
+	if err != nil {
+		return abi.Method{}, err
+	}
+	parsed, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return abi.Method{}, fmt.Errorf("invalid method signature %q: %w", signature, err)
+	}
+	return parsed.Methods[selector.Name], nil
+}
+
+// ContractMethod is a value of the ContractMethodSigWhitelist.
+type ContractMethod struct {
+	Selector  string `json:"selector"`
+	Signature string `json:"signature,omitempty"` // Signature is empty if the selector is not in the MethodSet.
+}
+
+// AddContractMethods adds the selectors of methods, resolved with set.Selectors, to the
+// ContractMethodSigWhitelist of a policy, like AddToWhitelistChunked.
+func AddContractMethods(ctx context.Context, c Client, policyUUID uuid.UUID, set *MethodSet, opts *ChunkOptions, methods ...string) (*WhitelistReport, error) {
+	selectors, err := set.Selectors(methods...)
+	if err != nil {
+		return &WhitelistReport{}, err
+	}
+	return AddToWhitelistChunked(ctx, c, WhiteListArgs{PolicyUUID: policyUUID, WhitelistType: ContractMethodSigWhitelist, Values: selectors}, opts)
+}
+
+// RmContractMethods removes the selectors of methods, resolved with set.Selectors, from the
+// ContractMethodSigWhitelist of a policy, like RmFromWhitelistChunked.
+func RmContractMethods(ctx context.Context, c Client, policyUUID uuid.UUID, set *MethodSet, opts *ChunkOptions, methods ...string) (*WhitelistReport, error) {
+	selectors, err := set.Selectors(methods...)
+	if err != nil {
+		return &WhitelistReport{}, err
+	}
+	return RmFromWhitelistChunked(ctx, c, WhiteListArgs{PolicyUUID: policyUUID, WhitelistType: ContractMethodSigWhitelist, Values: selectors}, opts)
+}
+
+// ListContractMethods returns the ContractMethodSigWhitelist of a policy, with the signatures of the
+// selectors found in set, which may be nil.
+func ListContractMethods(ctx context.Context, c Client, policyUUID uuid.UUID, set *MethodSet) ([]ContractMethod, error) {
+	selectors, err := ListWhitelist(ctx, c, GetWhitelistArgs{PolicyUUID: policyUUID, WhitelistType: ContractMethodSigWhitelist})
+	methods := make([]ContractMethod, len(selectors))
+	for i, selector := range selectors {
+		methods[i].Selector = selector
+		methods[i].Signature, _ = set.Signature(selector)
+	}
+	return methods, err
+}
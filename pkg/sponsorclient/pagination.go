@@ -0,0 +1,159 @@
+package sponsorclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MaxOffset bounds GetWhitelistArgs: the server rejects an Offset or a Limit that is not less than MaxOffset,
+// so only the first MaxOffset values of a whitelist can be listed.
+const MaxOffset = 1000
+
+// DefaultWhitelistPageSize is the page size of a WhitelistIterator when GetWhitelistArgs.Limit is not set.
+const DefaultWhitelistPageSize = 100
+
+// ErrWhitelistTruncated is returned by a WhitelistIterator that reached MaxOffset before the end of the whitelist.
+var ErrWhitelistTruncated = errors.New("whitelist has more values than MaxOffset allows to list")
+
+// WhitelistPage is a page of whitelist values returned by GetWhitelistPage.
+type WhitelistPage struct {
+	Values []string `json:"values"`
+	// Total is the number of values of the whole whitelist, or -1 if the server did not report it.
+	Total int `json:"total"`
+}
+
+// UnmarshalJSON accepts both a bare array of values and an object holding the values and the total count.
+func (p *WhitelistPage) UnmarshalJSON(data []byte) error {
+	var values []string
+	if err := json.Unmarshal(data, &values); err == nil {
+		*p = WhitelistPage{Values: values, Total: -1}
+		if p.Values == nil {
+			p.Values = []string{}
+		}
+		return nil
+	}
+
+	var obj struct {
+		Values    []string `json:"values"`
+		List      []string `json:"list"`
+		Total     *int     `json:"total"`
+		TotalSize *int     `json:"totalSize"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("invalid whitelist page: %w", err)
+	}
+	*p = WhitelistPage{Values: obj.Values, Total: -1}
+	if p.Values == nil {
+		p.Values = obj.List
+	}
+	if p.Values == nil {
+		p.Values = []string{}
+	}
+	switch {
+	case obj.Total != nil:
+		p.Total = *obj.Total
+	case obj.TotalSize != nil:
+		p.Total = *obj.TotalSize
+	}
+	return nil
+}
+
+// WhitelistIterator walks a whole whitelist one page at a time:
+//
+//	it := sponsorclient.NewWhitelistIterator(client, args)
+//	for it.Next(ctx) {
+//		for _, value := range it.Page() {
+//			...
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type WhitelistIterator struct {
+	c    Client
+	args GetWhitelistArgs
+	page []string
+	// total is the total count reported with the last page, -1 if none.
+	total int
+	done  bool
+	err   error
+}
+
+// NewWhitelistIterator returns an iterator over the whitelist selected by args, starting at args.Offset and
+// fetching args.Limit values per page, DefaultWhitelistPageSize if zero. Limits of MaxOffset or more are lowered.
+func NewWhitelistIterator(c Client, args GetWhitelistArgs) *WhitelistIterator {
+	if args.Limit <= 0 {
+		args.Limit = DefaultWhitelistPageSize
+	}
+	args.Limit = min(args.Limit, MaxOffset-1)
+	return &WhitelistIterator{c: c, args: args, total: -1}
+}
+
+// Next fetches the next page and reports whether there is one. It returns false at the end of the
+// whitelist or on error, which Err then returns.
+func (it *WhitelistIterator) Next(ctx context.Context) bool {
+	if it.done {
+		return false
+	}
+	if it.args.Offset >= MaxOffset {
+		it.done, it.err = true, it.truncated(ctx)
+		return false
+	}
+
+	page, err := it.c.GetWhitelistPage(ctx, it.args)
+	if err != nil {
+		it.done, it.err = true, err
+		return false
+	}
+	it.page = page.Values
+	it.total = page.Total
+	it.args.Offset += len(page.Values)
+	if len(page.Values) < it.args.Limit || page.Total >= 0 && it.args.Offset >= page.Total {
+		it.done = true
+	}
+	return len(page.Values) > 0
+}
+
+// truncated returns ErrWhitelistTruncated if the whitelist goes on past MaxOffset. Without a total count,
+// it fetches the last value that can be listed along with the next one, which only comes back if it exists.
+func (it *WhitelistIterator) truncated(ctx context.Context) error {
+	if it.total >= 0 {
+		if it.total > MaxOffset {
+			return ErrWhitelistTruncated
+		}
+		return nil
+	}
+	args := it.args
+	args.Offset, args.Limit = MaxOffset-1, 2
+	page, err := it.c.GetWhitelistPage(ctx, args)
+	if err != nil {
+		return err
+	}
+	if len(page.Values) > 1 {
+		return ErrWhitelistTruncated
+	}
+	return nil
+}
+
+// Page returns the values of the page fetched by the last call to Next.
+func (it *WhitelistIterator) Page() []string {
+	return it.page
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *WhitelistIterator) Err() error {
+	return it.err
+}
+
+// ListWhitelist returns all values of the whitelist selected by args, from args.Offset on, fetching them
+// page by page. The values fetched so far are returned along with any error.
+func ListWhitelist(ctx context.Context, c Client, args GetWhitelistArgs) ([]string, error) {
+	values := []string{}
+	it := NewWhitelistIterator(c, args)
+	for it.Next(ctx) {
+		values = append(values, it.Page()...)
+	}
+	return values, it.Err()
+}
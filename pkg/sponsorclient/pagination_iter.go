@@ -0,0 +1,29 @@
+package sponsorclient
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns the remaining values of the whitelist as a sequence, fetching pages as needed. An error
+// ends the sequence and is yielded with an empty value.
+func (it *WhitelistIterator) All(ctx context.Context) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for it.Next(ctx) {
+			for _, value := range it.Page() {
+				if !yield(value, nil) {
+					return
+				}
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield("", err)
+		}
+	}
+}
+
+// WhitelistValues returns the values of the whitelist selected by args as a sequence, like
+// NewWhitelistIterator(c, args).All(ctx).
+func WhitelistValues(ctx context.Context, c Client, args GetWhitelistArgs) iter.Seq2[string, error] {
+	return NewWhitelistIterator(c, args).All(ctx)
+}
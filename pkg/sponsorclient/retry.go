@@ -0,0 +1,64 @@
+package sponsorclient
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/retry"
+)
+
+type retryClient struct {
+	c      Client
+	policy *retry.Policy
+}
+
+// NewRetryClient wraps c so that transient failures are retried with the backoff of policy, or
+// retry.DefaultPolicy if nil. Reads are retried on any transient failure, whitelist changes only
+// when the failure shows the call never reached the endpoint.
+func NewRetryClient(c Client, policy *retry.Policy) Client {
+	return &retryClient{c, policy}
+}
+
+func (r *retryClient) AddToWhitelist(ctx context.Context, args WhiteListArgs) (bool, error) {
+	return retry.Call(ctx, r.policy, retry.IsNotSent, func(ctx context.Context) (bool, error) {
+		return r.c.AddToWhitelist(ctx, args)
+	})
+}
+
+func (r *retryClient) RmFromWhitelist(ctx context.Context, args WhiteListArgs) (bool, error) {
+	return retry.Call(ctx, r.policy, retry.IsNotSent, func(ctx context.Context) (bool, error) {
+		return r.c.RmFromWhitelist(ctx, args)
+	})
+}
+
+func (r *retryClient) EmptyWhitelist(ctx context.Context, args EmptyWhiteListArgs) (bool, error) {
+	return retry.Call(ctx, r.policy, retry.IsNotSent, func(ctx context.Context) (bool, error) {
+		return r.c.EmptyWhitelist(ctx, args)
+	})
+}
+
+func (r *retryClient) GetWhitelist(ctx context.Context, args GetWhitelistArgs) (interface{}, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (interface{}, error) {
+		return r.c.GetWhitelist(ctx, args)
+	})
+}
+
+func (r *retryClient) GetWhitelistPage(ctx context.Context, args GetWhitelistArgs) (*WhitelistPage, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*WhitelistPage, error) {
+		return r.c.GetWhitelistPage(ctx, args)
+	})
+}
+
+func (r *retryClient) GetUserSpendData(ctx context.Context, fromAddress common.Address, policyUUID uuid.UUID) (*UserSpendData, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*UserSpendData, error) {
+		return r.c.GetUserSpendData(ctx, fromAddress, policyUUID)
+	})
+}
+
+func (r *retryClient) GetPolicySpendData(ctx context.Context, policyUUID uuid.UUID) (*PolicySpendData, error) {
+	return retry.Call(ctx, r.policy, retry.IsRetryable, func(ctx context.Context) (*PolicySpendData, error) {
+		return r.c.GetPolicySpendData(ctx, policyUUID)
+	})
+}
@@ -27,7 +27,7 @@ const (
 type WhiteListArgs struct {
 	PolicyUUID    uuid.UUID     `json:"policyUuid"`    // The uuid of policy for which this request is attempt to add the white list values  . Required.
 	WhitelistType WhitelistType `json:"whitelistType"` // enum, supported values are "FromAccountWhitelist", "ToAccountWhitelist", "ContractMethodSigWhitelist", "BEP20ReceiverWhiteList"
-	Values        []string      `json:"values"`        // a list of values for given WhitelistType.  The max length of this list is WhiteListDataMaxBatchSize. To insert more than WhiteListDataMaxBatchSize records, please invoke this API multiple times.
+	Values        []string      `json:"values"`        // a list of values for given WhitelistType.  The server bounds the length of this list. To update more records, use AddToWhitelistChunked or RmFromWhitelistChunked.
 }
 
 type EmptyWhiteListArgs struct {
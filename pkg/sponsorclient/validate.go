@@ -0,0 +1,131 @@
+package sponsorclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+)
+
+// InvalidValue is a whitelist value rejected by validation.
+type InvalidValue struct {
+	Index  int    // Index is the position of the value in WhiteListArgs.Values.
+	Value  string // Value is the value as given.
+	Reason string // Reason tells what is wrong with the value.
+}
+
+// ValidationError lists the values of a whitelist update that failed validation. AddToWhitelist and
+// RmFromWhitelist return it, wrapped in an *mferrors.Error of kind mferrors.ErrInvalidParams, before
+// sending anything.
+type ValidationError struct {
+	WhitelistType WhitelistType
+	Invalid       []InvalidValue
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid %s values at indices %s", e.WhitelistType, joinInts(e.Indices()))
+	for i, v := range e.Invalid {
+		if i == 3 {
+			fmt.Fprintf(&b, "; and %d more", len(e.Invalid)-i)
+			break
+		}
+		fmt.Fprintf(&b, "; %d: %q %s", v.Index, v.Value, v.Reason)
+	}
+	return b.String()
+}
+
+// Indices returns the indices of the invalid values, in increasing order.
+func (e *ValidationError) Indices() []int {
+	indices := make([]int, len(e.Invalid))
+	for i, v := range e.Invalid {
+		indices[i] = v.Index
+	}
+	return indices
+}
+
+func joinInts(ints []int) string {
+	s := make([]string, len(ints))
+	for i, n := range ints {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ", ")
+}
+
+// NormalizeValue validates a value of the whitelist type t and returns it in canonical form:
+// addresses of the account whitelists are EIP-55 checksummed, method selectors of
+// ContractMethodSigWhitelist are 0x-prefixed lower-case 4-byte hex strings. Mixed-case addresses
+// must carry a valid checksum. Values of unknown whitelist types are returned as is.
+func (t WhitelistType) NormalizeValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch t {
+	case FromAccountWhitelist, ToAccountWhitelist, BEP20ReceiverWhiteList:
+		return normalizeAddress(value)
+	case ContractMethodSigWhitelist:
+		return normalizeSelector(value)
+	}
+	return value, nil
+}
+
+func normalizeAddress(value string) (string, error) {
+	if !has0xPrefix(value) || !common.IsHexAddress(value) {
+		return "", fmt.Errorf("is not a 0x-prefixed 20-byte hex address")
+	}
+	address := common.HexToAddress(value).Hex()
+	digits := value[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && value != address {
+		return "", fmt.Errorf("has an invalid checksum, expected %s", address)
+	}
+	return address, nil
+}
+
+func normalizeSelector(value string) (string, error) {
+	if !has0xPrefix(value) {
+		return "", fmt.Errorf("is not a 0x-prefixed 4-byte method selector")
+	}
+	b, err := hexutil.Decode(strings.ToLower(value[:2]) + value[2:])
+	if err != nil || len(b) != 4 {
+		return "", fmt.Errorf("is not a 0x-prefixed 4-byte method selector")
+	}
+	return hexutil.Encode(b), nil
+}
+
+func has0xPrefix(s string) bool {
+	return len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
+
+// NormalizeWhitelistValues normalizes every value with t.NormalizeValue. If any value is invalid, it
+// returns a *ValidationError naming all of them.
+func NormalizeWhitelistValues(t WhitelistType, values []string) ([]string, error) {
+	normalized := make([]string, len(values))
+	var verr *ValidationError
+	for i, value := range values {
+		v, err := t.NormalizeValue(value)
+		if err != nil {
+			if verr == nil {
+				verr = &ValidationError{WhitelistType: t}
+			}
+			verr.Invalid = append(verr.Invalid, InvalidValue{Index: i, Value: value, Reason: err.Error()})
+			continue
+		}
+		normalized[i] = v
+	}
+	if verr != nil {
+		return nil, verr
+	}
+	return normalized, nil
+}
+
+// normalizeArgs returns args with normalized values, or the validation error as an unsent call of method.
+func normalizeArgs(method string, args WhiteListArgs) (WhiteListArgs, error) {
+	values, err := NormalizeWhitelistValues(args.WhitelistType, args.Values)
+	if err != nil {
+		return args, &mferrors.Error{Method: method, Message: err.Error(), Kind: mferrors.ErrInvalidParams, Err: err}
+	}
+	args.Values = values
+	return args, nil
+}
@@ -0,0 +1,145 @@
+package sponsorclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultChunkConcurrency is the number of chunks sent at the same time when ChunkOptions.Concurrency is not set.
+const DefaultChunkConcurrency = 4
+
+var (
+	// ErrWhitelistNotUpdated is the error of a chunk for which the server answered false.
+	ErrWhitelistNotUpdated = errors.New("whitelist not updated")
+	// ErrNoChunkSize is returned by the chunked whitelist updates when ChunkOptions.ChunkSize is not set.
+	ErrNoChunkSize = errors.New("whitelist chunk size not set")
+)
+
+// ChunkOptions configures AddToWhitelistChunked and RmFromWhitelistChunked.
+type ChunkOptions struct {
+	// ChunkSize is the number of values per call. It is required and must not exceed the maximum number of
+	// values the server accepts in a single AddToWhitelist or RmFromWhitelist call, which has no default here.
+	ChunkSize int
+	// Concurrency is the maximum number of calls in flight, DefaultChunkConcurrency if zero.
+	Concurrency int
+}
+
+// ChunkResult is the outcome of the call for one chunk of values.
+type ChunkResult struct {
+	Values []string // Values are the values of the chunk, in input order.
+	Err    error    // Err is nil if the chunk was applied.
+}
+
+// WhitelistReport is the outcome of a chunked whitelist update, one ChunkResult per chunk in input order.
+type WhitelistReport struct {
+	Chunks []ChunkResult
+}
+
+// Succeeded returns the values of the chunks that were applied, in input order.
+func (r *WhitelistReport) Succeeded() []string {
+	return r.values(true)
+}
+
+// Failed returns the values of the chunks that failed, in input order. They can be passed to a new
+// chunked call to retry them.
+func (r *WhitelistReport) Failed() []string {
+	return r.values(false)
+}
+
+func (r *WhitelistReport) values(succeeded bool) []string {
+	var values []string
+	for _, chunk := range r.Chunks {
+		if (chunk.Err == nil) == succeeded {
+			values = append(values, chunk.Values...)
+		}
+	}
+	return values
+}
+
+// Err returns nil if every chunk was applied, otherwise an error counting the failed chunks and wrapping
+// the error of the first of them.
+func (r *WhitelistReport) Err() error {
+	var (
+		failed   int
+		firstErr error
+	)
+	for _, chunk := range r.Chunks {
+		if chunk.Err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = chunk.Err
+			}
+		}
+	}
+	if firstErr == nil {
+		return nil
+	}
+	return fmt.Errorf("%d of %d whitelist chunks failed: %w", failed, len(r.Chunks), firstErr)
+}
+
+// AddToWhitelistChunked adds any number of values to the whitelist of a policy, splitting them into
+// chunks of opts.ChunkSize values sent concurrently. The report is always returned; the error is the
+// report's Err. All values are validated first: if any is invalid, nothing is sent and the error holds a
+// *ValidationError. Without a chunk size, nothing is sent and the error is ErrNoChunkSize.
+func AddToWhitelistChunked(ctx context.Context, c Client, args WhiteListArgs, opts *ChunkOptions) (*WhitelistReport, error) {
+	return chunkWhitelist(ctx, "pm_addToWhitelist", args, opts, c.AddToWhitelist)
+}
+
+// RmFromWhitelistChunked removes any number of values from the whitelist of a policy, splitting them
+// into chunks sent concurrently, like AddToWhitelistChunked.
+func RmFromWhitelistChunked(ctx context.Context, c Client, args WhiteListArgs, opts *ChunkOptions) (*WhitelistReport, error) {
+	return chunkWhitelist(ctx, "pm_rmFromWhitelist", args, opts, c.RmFromWhitelist)
+}
+
+func chunkWhitelist(ctx context.Context, method string, args WhiteListArgs, opts *ChunkOptions, call func(context.Context, WhiteListArgs) (bool, error)) (*WhitelistReport, error) {
+	if opts == nil || opts.ChunkSize <= 0 {
+		return &WhitelistReport{}, ErrNoChunkSize
+	}
+	args, err := normalizeArgs(method, args)
+	if err != nil {
+		return &WhitelistReport{}, err
+	}
+
+	size, concurrency := opts.ChunkSize, DefaultChunkConcurrency
+	if opts.Concurrency > 0 {
+		concurrency = opts.Concurrency
+	}
+
+	report := &WhitelistReport{}
+	for start := 0; start < len(args.Values); start += size {
+		end := min(start+size, len(args.Values))
+		report.Chunks = append(report.Chunks, ChunkResult{Values: args.Values[start:end:end]})
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for i := range report.Chunks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			report.Chunks[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(chunk *ChunkResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			chunkArgs := args
+			chunkArgs.Values = chunk.Values
+			ok, err := call(ctx, chunkArgs)
+			if err == nil && !ok {
+				err = ErrWhitelistNotUpdated
+			}
+			chunk.Err = err
+		}(&report.Chunks[i])
+	}
+	wg.Wait()
+	return report, report.Err()
+}
@@ -0,0 +1,337 @@
+// Package whitelistsync reconciles the whitelists of MegaFuel policies with a desired state kept in a
+// YAML or JSON file:
+//
+//	3f8e9b52-4c1d-4a8e-9d3c-2b7a1e6f0c5d:
+//	  FromAccountWhitelist:
+//	    - 0xDE08B1Fd79b7016F8DD3Df11f7fa0FbfdF07c941
+//	  ContractMethodSigWhitelist:
+//	    - transfer(address,uint256)
+//	    - 0x095ea7b3
+//	  ToAccountWhitelist: []
+//
+// Only the listed whitelists are managed: an empty list empties the whitelist, a missing one is left
+// alone. NewPlan compares the desired state with the current one and Apply makes the additions and
+// removals of the plan.
+package whitelistsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+// ErrNotConfirmed is returned by Reconcile when the plan was not confirmed.
+var ErrNotConfirmed = errors.New("plan not confirmed")
+
+// Config is the desired state of the whitelists: policy UUID → whitelist type → values.
+type Config map[uuid.UUID]map[sponsorclient.WhitelistType][]string
+
+// Parse parses a desired state in YAML or JSON.
+func Parse(data []byte) (Config, error) {
+	var raw map[string]map[sponsorclient.WhitelistType][]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid whitelist config: %w", err)
+	}
+	config := make(Config, len(raw))
+	for key, whitelists := range raw {
+		policyUUID, err := uuid.FromString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid whitelist config: invalid policy uuid %q", key)
+		}
+		if whitelists == nil {
+			whitelists = make(map[sponsorclient.WhitelistType][]string)
+		}
+		config[policyUUID] = whitelists
+	}
+	return config, nil
+}
+
+// Load reads and parses the desired state in the YAML or JSON file path.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Change is the difference between the desired and the current state of one whitelist.
+type Change struct {
+	PolicyUUID    uuid.UUID                   `json:"policyUuid"`
+	WhitelistType sponsorclient.WhitelistType `json:"whitelistType"`
+	Add           []string                    `json:"add,omitempty"`    // Add holds the desired values missing from the whitelist.
+	Remove        []string                    `json:"remove,omitempty"` // Remove holds the values of the whitelist that are not desired.
+	// Empty is true if no value is desired, so that the whitelist is emptied rather than having Remove removed.
+	Empty bool `json:"empty,omitempty"`
+}
+
+// Plan is the list of changes bringing the whitelists to the desired state, ordered by policy and type.
+// Whitelists already in the desired state have no change.
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// IsEmpty reports whether the whitelists already are in the desired state.
+func (p *Plan) IsEmpty() bool {
+	return len(p.Changes) == 0
+}
+
+// IsChunked reports whether applying the plan adds or removes values with the chunked whitelist updates,
+// which need a chunk size. Emptied whitelists are not.
+func (p *Plan) IsChunked() bool {
+	for _, change := range p.Changes {
+		if !change.Empty && (len(change.Add) > 0 || len(change.Remove) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts returns the number of values the plan adds and removes.
+func (p *Plan) Counts() (add, remove int) {
+	for _, change := range p.Changes {
+		add += len(change.Add)
+		remove += len(change.Remove)
+	}
+	return add, remove
+}
+
+// WriteTo writes the plan as a diff, additions prefixed with + and removals with -.
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, change := range p.Changes {
+		fmt.Fprintf(&b, "policy %s %s", change.PolicyUUID, change.WhitelistType)
+		if change.Empty {
+			b.WriteString(" (empty)")
+		}
+		b.WriteString(":\n")
+		for _, value := range change.Add {
+			fmt.Fprintf(&b, "  + %s\n", value)
+		}
+		for _, value := range change.Remove {
+			fmt.Fprintf(&b, "  - %s\n", value)
+		}
+	}
+	add, remove := p.Counts()
+	if p.IsEmpty() {
+		b.WriteString("No changes, the whitelists are up to date.\n")
+	} else {
+		fmt.Fprintf(&b, "Plan: %d to add, %d to remove.\n", add, remove)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (p *Plan) String() string {
+	var b strings.Builder
+	p.WriteTo(&b)
+	return b.String()
+}
+
+// NewPlan reads the current state of the whitelists of desired and returns the plan reaching the desired
+// state. The desired values are validated and normalized first, see sponsorclient.NormalizeWhitelistValues;
+// the values of ContractMethodSigWhitelist may also be method signatures.
+func NewPlan(ctx context.Context, c sponsorclient.Client, desired Config) (*Plan, error) {
+	normalized, err := normalize(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	for _, policyUUID := range sortedPolicies(normalized) {
+		whitelists := normalized[policyUUID]
+		for _, whitelistType := range sortedTypes(whitelists) {
+			current, err := sponsorclient.ListWhitelist(ctx, c, sponsorclient.GetWhitelistArgs{PolicyUUID: policyUUID, WhitelistType: whitelistType})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s of policy %s: %w", whitelistType, policyUUID, err)
+			}
+			change := diff(whitelists[whitelistType], current)
+			if len(change.Add) == 0 && len(change.Remove) == 0 {
+				continue
+			}
+			change.PolicyUUID, change.WhitelistType = policyUUID, whitelistType
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+	return plan, nil
+}
+
+// normalize returns the desired values normalized and without duplicates, in their original order.
+func normalize(desired Config) (Config, error) {
+	normalized := make(Config, len(desired))
+	for policyUUID, whitelists := range desired {
+		normalized[policyUUID] = make(map[sponsorclient.WhitelistType][]string, len(whitelists))
+		for whitelistType, values := range whitelists {
+			var err error
+			if whitelistType == sponsorclient.ContractMethodSigWhitelist {
+				values, err = (*sponsorclient.MethodSet)(nil).Selectors(values...)
+			} else {
+				values, err = sponsorclient.NormalizeWhitelistValues(whitelistType, values)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: %w", policyUUID, err)
+			}
+			normalized[policyUUID][whitelistType] = dedup(values)
+		}
+	}
+	return normalized, nil
+}
+
+// diff compares the desired values with the current ones, ignoring case like the server does.
+func diff(desired, current []string) Change {
+	currentSet := make(map[string]bool, len(current))
+	for _, value := range current {
+		currentSet[strings.ToLower(value)] = true
+	}
+	desiredSet := make(map[string]bool, len(desired))
+	var change Change
+	for _, value := range desired {
+		desiredSet[strings.ToLower(value)] = true
+		if !currentSet[strings.ToLower(value)] {
+			change.Add = append(change.Add, value)
+		}
+	}
+	for _, value := range current {
+		if !desiredSet[strings.ToLower(value)] {
+			change.Remove = append(change.Remove, value)
+		}
+	}
+	change.Empty = len(desired) == 0 && len(current) > 0
+	return change
+}
+
+func dedup(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := values[:0:0]
+	for _, value := range values {
+		if !seen[strings.ToLower(value)] {
+			seen[strings.ToLower(value)] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func sortedPolicies(config Config) []uuid.UUID {
+	policies := make([]uuid.UUID, 0, len(config))
+	for policyUUID := range config {
+		policies = append(policies, policyUUID)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].String() < policies[j].String() })
+	return policies
+}
+
+func sortedTypes(whitelists map[sponsorclient.WhitelistType][]string) []sponsorclient.WhitelistType {
+	types := make([]sponsorclient.WhitelistType, 0, len(whitelists))
+	for whitelistType := range whitelists {
+		types = append(types, whitelistType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Apply makes the changes of plan, the additions of a whitelist before its removals so that desired values
+// are never missing. It goes on after a failed change and returns the errors of all failed changes. Values
+// are added and removed in chunks configured by opts; if the plan IsChunked and opts has no chunk size,
+// nothing is changed and sponsorclient.ErrNoChunkSize is returned.
+func Apply(ctx context.Context, c sponsorclient.Client, plan *Plan, opts *sponsorclient.ChunkOptions) error {
+	if err := checkChunks(plan, opts); err != nil {
+		return err
+	}
+	var errs []error
+	for _, change := range plan.Changes {
+		if err := apply(ctx, c, change, opts); err != nil {
+			errs = append(errs, fmt.Errorf("policy %s %s: %w", change.PolicyUUID, change.WhitelistType, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func apply(ctx context.Context, c sponsorclient.Client, change Change, opts *sponsorclient.ChunkOptions) error {
+	if change.Empty {
+		ok, err := c.EmptyWhitelist(ctx, sponsorclient.EmptyWhiteListArgs{PolicyUUID: change.PolicyUUID, WhitelistType: change.WhitelistType})
+		if err == nil && !ok {
+			err = sponsorclient.ErrWhitelistNotUpdated
+		}
+		return err
+	}
+	args := sponsorclient.WhiteListArgs{PolicyUUID: change.PolicyUUID, WhitelistType: change.WhitelistType}
+	if len(change.Add) > 0 {
+		args.Values = change.Add
+		if _, err := sponsorclient.AddToWhitelistChunked(ctx, c, args, opts); err != nil {
+			return err
+		}
+	}
+	if len(change.Remove) > 0 {
+		args.Values = change.Remove
+		if _, err := sponsorclient.RmFromWhitelistChunked(ctx, c, args, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Options configures Reconcile.
+type Options struct {
+	// Out receives the plan, nothing is written if nil.
+	Out io.Writer
+	// DryRun stops after the plan is written.
+	DryRun bool
+	// Confirm is called with a non-empty plan before it is applied; the plan is applied only if it returns
+	// true. A nil Confirm applies the plan without asking.
+	Confirm func(plan *Plan) (bool, error)
+	// Chunk configures the chunked whitelist updates, see sponsorclient.AddToWhitelistChunked. Its ChunkSize
+	// is required by plans adding or removing values.
+	Chunk *sponsorclient.ChunkOptions
+}
+
+// Reconcile plans the changes reaching the desired state, writes the plan to opts.Out and applies it unless
+// it is a dry run or the plan is not confirmed, in which case ErrNotConfirmed is returned. The plan is
+// returned in all cases once it has been made.
+func Reconcile(ctx context.Context, c sponsorclient.Client, desired Config, opts *Options) (*Plan, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	plan, err := NewPlan(ctx, c, desired)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Out != nil {
+		if _, err := plan.WriteTo(opts.Out); err != nil {
+			return plan, err
+		}
+	}
+	if opts.DryRun || plan.IsEmpty() {
+		return plan, nil
+	}
+	if err := checkChunks(plan, opts.Chunk); err != nil {
+		return plan, err
+	}
+	if opts.Confirm != nil {
+		ok, err := opts.Confirm(plan)
+		if err != nil {
+			return plan, err
+		}
+		if !ok {
+			return plan, ErrNotConfirmed
+		}
+	}
+	return plan, Apply(ctx, c, plan, opts.Chunk)
+}
+
+// checkChunks returns sponsorclient.ErrNoChunkSize if plan IsChunked and opts has no chunk size.
+func checkChunks(plan *Plan, opts *sponsorclient.ChunkOptions) error {
+	if plan.IsChunked() && (opts == nil || opts.ChunkSize <= 0) {
+		return sponsorclient.ErrNoChunkSize
+	}
+	return nil
+}
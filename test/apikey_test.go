@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/apikey"
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+const testAPIKey = "0123456789abcdef0123456789abcdef"
+
+// TestAPIKeyCredential checks that the clients put the current key of their credential in the path of each request.
+func TestAPIKeyCredential(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	ctx := context.Background()
+	lastPath := func() string {
+		requests := server.Requests()
+		return requests[len(requests)-1].Path
+	}
+
+	// Keys read from a file are read again once the file changes.
+	path := filepath.Join(t.TempDir(), "apikey")
+	require.NoError(t, os.WriteFile(path, []byte("key1\n"), 0o600))
+	sponsor, err := sponsorclient.NewWithCredential(ctx, server.URL+"/{apikey}/megafuel", apikey.FromFile(path))
+	require.NoError(t, err)
+	_, err = sponsor.GetPolicySpendData(ctx, policyUUID)
+	require.ErrorIs(t, err, mferrors.ErrNotFound)
+	assert.Equal(t, "/key1/megafuel", lastPath())
+
+	require.NoError(t, os.WriteFile(path, []byte("rotated-key2\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	_, err = sponsor.GetPolicySpendData(ctx, policyUUID)
+	require.ErrorIs(t, err, mferrors.ErrNotFound)
+	assert.Equal(t, "/rotated-key2/megafuel", lastPath())
+
+	// Keys read from the environment follow it.
+	t.Setenv("TEST_MEGAFUEL_API_KEY", "key3")
+	paymaster, err := paymasterclient.NewPrivatePaymasterWithCredential(ctx, server.URL+"/{apikey}/megafuel/97", apikey.FromEnv("TEST_MEGAFUEL_API_KEY"), policyUUID.String())
+	require.NoError(t, err)
+	_, err = paymaster.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "/key3/megafuel/97", lastPath())
+
+	// A missing key fails the call before it is sent.
+	t.Setenv("TEST_MEGAFUEL_API_KEY", "")
+	count := len(server.Requests())
+	_, err = paymaster.ChainID(ctx)
+	require.ErrorIs(t, err, apikey.ErrNoAPIKey)
+	assert.Len(t, server.Requests(), count)
+
+	_, err = sponsorclient.NewWithCredential(ctx, server.URL+"/"+testAPIKey+"/megafuel", apikey.Static(testAPIKey))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), testAPIKey)
+}
+
+// TestAPIKeyRedaction checks that API keys never show in printed URLs, credentials and errors.
+func TestAPIKeyRedaction(t *testing.T) {
+	endpoint := "https://open-platform-ap.nodereal.io/" + testAPIKey + "/megafuel?token=secret"
+	redacted := "https://open-platform-ap.nodereal.io/{apikey}/megafuel"
+
+	assert.Equal(t, redacted, apikey.URL(endpoint).String())
+	assert.Equal(t, redacted, fmt.Sprintf("%v", apikey.URL(endpoint)))
+	assert.NotContains(t, fmt.Sprintf("%#v", apikey.URL(endpoint)), testAPIKey)
+	data, err := json.Marshal(struct{ Endpoint apikey.URL }{apikey.URL(endpoint)})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Endpoint":"`+redacted+`"}`, string(data))
+
+	assert.Equal(t, "{apikey}", fmt.Sprint(apikey.Static("short")))
+	assert.NotContains(t, fmt.Sprintf("%#v", apikey.Static("short")), "short")
+	assert.Equal(t, `Post "`+redacted+`": dial failed, key {apikey}`, apikey.Redact(`Post "`+endpoint+`": dial failed, key short`, "short"))
+
+	base := errors.New("dial " + endpoint)
+	err = apikey.RedactError(base)
+	assert.Equal(t, "dial "+redacted, err.Error())
+	assert.ErrorIs(t, err, base)
+	assert.NoError(t, apikey.RedactError(nil))
+
+	// The transport errors of a client dialed with the key in its URL are redacted.
+	sponsor, err := sponsorclient.New(context.Background(), "http://127.0.0.1:1/"+testAPIKey+"/megafuel")
+	require.NoError(t, err)
+	_, err = sponsor.GetPolicySpendData(context.Background(), uuid.Must(uuid.NewV4()))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, mferrors.ErrUnavailable)
+	assert.NotContains(t, err.Error(), testAPIKey)
+	assert.True(t, strings.Contains(err.Error(), "{apikey}"), err.Error())
+	var e *mferrors.Error
+	require.ErrorAs(t, err, &e)
+	assert.NotContains(t, e.Err.Error(), testAPIKey)
+
+	// So are the dial errors of every constructor.
+	badURL := "http://127.0.0.1:1/" + testAPIKey + "/%zz"
+	_, err = paymasterclient.New(context.Background(), badURL)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), testAPIKey)
+	_, err = paymasterclient.NewForNetwork(context.Background(), networks.Network{Name: "custom", ChainID: 97, PaymasterURL: badURL})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), testAPIKey)
+}
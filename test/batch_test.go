@@ -0,0 +1,56 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestBatchGetGaslessTransactions mixes known and unknown hashes and checks the per-item results.
+func TestBatchGetGaslessTransactions(t *testing.T) {
+	_, client := startMegaFuel(t, nil)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(client, paymasterclient.NewPrivateKeySigner(privateKey), nil)
+
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+	result, err := sender.Send(context.Background(), paymasterclient.GaslessRequest{To: &toAddress})
+	require.NoError(t, err)
+
+	unknown := common.HexToHash("0x01")
+	results, err := client.BatchGetGaslessTransactions(context.Background(), []common.Hash{result.TxHash, unknown})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, result.TxHash, results[0].Result.TxHash)
+	assert.Nil(t, results[1].Result)
+	assert.ErrorIs(t, results[1].Err, mferrors.ErrNotFound)
+}
+
+// TestBatchIsSponsorableChunks checks that large inputs are split into several batch requests.
+func TestBatchIsSponsorableChunks(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+
+	txs := make([]paymasterclient.TransactionArgs, 2*paymasterclient.BatchChunkSize+1)
+	for i := range txs {
+		txs[i].From = common.BigToAddress(common.Big1)
+	}
+	before := len(server.Requests())
+	results, err := client.BatchIsSponsorable(context.Background(), txs)
+	require.NoError(t, err)
+	require.Len(t, results, len(txs))
+	for _, result := range results {
+		require.NoError(t, result.Err)
+		assert.True(t, result.Result.Sponsorable)
+	}
+	assert.Equal(t, 3, len(server.Requests())-before)
+}
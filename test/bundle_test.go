@@ -0,0 +1,79 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestExploreTransaction resolves a bundle of two transactions from one of them and the candidates, then
+// finds them without candidates in the block that includes the bundle once it is confirmed.
+func TestExploreTransaction(t *testing.T) {
+	server, client := startMegaFuel(t, &megafueltest.Options{BundleSize: 2})
+	ctx := context.Background()
+	chain, err := ethclient.Dial(server.URL)
+	require.NoError(t, err)
+	defer chain.Close()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(client, paymasterclient.NewPrivateKeySigner(privateKey), nil)
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+
+	// The third transaction opens a second bundle.
+	var txHashes []common.Hash
+	for i := 0; i < 3; i++ {
+		result, err := sender.Send(ctx, paymasterclient.GaslessRequest{To: &toAddress, Value: big.NewInt(int64(i))})
+		require.NoError(t, err)
+		txHashes = append(txHashes, result.TxHash)
+	}
+
+	view, err := paymasterclient.ExploreTransaction(ctx, client, chain, txHashes[0], txHashes[1], txHashes[2], common.HexToHash("0x01"))
+	require.NoError(t, err)
+	assert.Equal(t, paymasterclient.StatusNew, view.Bundle.Status)
+	require.Len(t, view.Transactions, 2)
+	assert.Equal(t, txHashes[0], view.Transactions[0].TxHash)
+	assert.Equal(t, txHashes[1], view.Transactions[1].TxHash)
+	require.NotNil(t, view.Transactions[1].Transaction)
+	assert.Equal(t, big.NewInt(1), view.Transactions[1].Transaction.Value())
+	assert.ErrorIs(t, view.Unresolved[common.HexToHash("0x01")], mferrors.ErrNotFound)
+
+	// Unconfirmed bundles are not in a block yet, only the candidates are found.
+	bundleUUID := view.Bundle.BundleUUID
+	view, err = paymasterclient.ExploreBundle(ctx, client, chain, bundleUUID)
+	require.NoError(t, err)
+	assert.Empty(t, view.Transactions)
+
+	server.ConfirmAll()
+	view, err = paymasterclient.ExploreBundle(ctx, client, chain, bundleUUID)
+	require.NoError(t, err)
+	assert.Equal(t, paymasterclient.StatusConfirmed, view.Bundle.Status)
+	require.Len(t, view.Transactions, 2)
+	assert.Equal(t, txHashes[0], view.Transactions[0].TxHash)
+	assert.Equal(t, txHashes[1], view.Transactions[1].TxHash)
+	assert.Empty(t, view.Unresolved)
+	require.NotNil(t, view.SponsorTx)
+	assert.Equal(t, paymasterclient.StatusConfirmed, view.SponsorTx.Status)
+	assert.Equal(t, uint64(2*21000), view.UserGasUsed)
+	assert.Positive(t, view.UserGasFee.Sign())
+	assert.Positive(t, view.SponsorGasFee.Sign())
+
+	// Candidates of the bundle are not listed twice, those of other bundles are ignored.
+	view, err = paymasterclient.ExploreBundle(ctx, client, chain, bundleUUID, txHashes...)
+	require.NoError(t, err)
+	assert.Len(t, view.Transactions, 2)
+
+	_, err = paymasterclient.ExploreBundle(ctx, client, chain, uuid.Must(uuid.NewV4()))
+	assert.ErrorIs(t, err, mferrors.ErrNotFound)
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/whitelistsync"
+)
+
+// TestCLI builds the megafuel command and runs its subcommands against a fake MegaFuel server.
+func TestCLI(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the megafuel command")
+	}
+	bin := filepath.Join(t.TempDir(), "megafuel")
+	build := exec.Command("go", "build", "-o", bin, "../cmd/megafuel")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	run := func(wantCode int, v interface{}, args ...string) string {
+		t.Helper()
+		cmd := exec.Command(bin, args...)
+		cmd.Env = append(os.Environ(), "MEGAFUEL_PRIVATE_KEY="+hexutil.Encode(crypto.FromECDSA(privateKey)), "MEGAFUEL_API_KEY=")
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		code := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		} else {
+			require.NoError(t, err)
+		}
+		require.Equal(t, wantCode, code, "megafuel %v: %s", args, stderr.String())
+		if v != nil {
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), v), stdout.String())
+		}
+		return stdout.String()
+	}
+	paymasterFlags := []string{"-paymaster-url", server.URL, "-o", "json"}
+	sponsorFlags := []string{"-sponsor-url", server.URL, "-policy", policyUUID.String(), "-o", "json"}
+
+	run(2, nil)
+	run(2, nil, "unknown")
+	run(2, nil, "status", "-o", "yaml", "0x01")
+	run(1, nil, append([]string{"whitelist", "list", "-type", "Unknown"}, sponsorFlags...)...)
+
+	run(1, nil, append([]string{"whitelist", "add", "-type", "FromAccountWhitelist"}, append(sponsorFlags, from)...)...)
+	run(0, nil, append([]string{"whitelist", "add", "-type", "FromAccountWhitelist", "-chunk-size", "100"}, append(sponsorFlags, from)...)...)
+	var page sponsorclient.WhitelistPage
+	run(0, &page, append([]string{"whitelist", "list", "-type", "FromAccountWhitelist"}, sponsorFlags...)...)
+	assert.Equal(t, []string{from}, page.Values)
+	run(0, &page, append([]string{"whitelist", "list", "-type", "FromAccountWhitelist", "-all", "-limit", "1"}, sponsorFlags...)...)
+	assert.Equal(t, []string{from}, page.Values)
+	assert.Contains(t, run(0, nil, "whitelist", "list", "-type", "FromAccountWhitelist", "-sponsor-url", server.URL, "-policy", policyUUID.String()), from)
+
+	abiFile := filepath.Join(t.TempDir(), "token.abi")
+	require.NoError(t, os.WriteFile(abiFile, []byte(tokenABI), 0o600))
+	run(0, nil, append(append([]string{"whitelist", "add", "-type", "ContractMethodSigWhitelist", "-abi", abiFile, "-chunk-size", "100"}, sponsorFlags...), "transfer", "0x12345678")...)
+	var methods []sponsorclient.ContractMethod
+	run(0, &methods, append([]string{"whitelist", "list", "-type", "ContractMethodSigWhitelist", "-abi", abiFile}, sponsorFlags...)...)
+	assert.Equal(t, []sponsorclient.ContractMethod{{Selector: "0xa9059cbb", Signature: "transfer(address,uint256)"}, {Selector: "0x12345678"}}, methods)
+
+	syncFile := filepath.Join(t.TempDir(), "whitelists.yaml")
+	require.NoError(t, os.WriteFile(syncFile, []byte(policyUUID.String()+":\n  ToAccountWhitelist:\n    - "+RECIPIENT_ADDRESS+"\n"), 0o600))
+	syncFlags := []string{"-sponsor-url", server.URL, "-chunk-size", "100"}
+	assert.Contains(t, run(0, nil, append(append([]string{"sync", "-dry-run"}, syncFlags...), syncFile)...), "+ "+RECIPIENT_ADDRESS)
+	run(1, nil, "sync", "-yes", "-sponsor-url", server.URL, syncFile)
+	assert.Contains(t, run(0, nil, append(append([]string{"sync"}, syncFlags...), syncFile)...), "Plan: 1 to add, 0 to remove.")
+	assert.Empty(t, server.Whitelist(policyUUID, sponsorclient.ToAccountWhitelist))
+	// In json mode the plan is shown on stderr before asking for confirmation.
+	prompt := exec.Command(bin, append(append([]string{"sync", "-o", "json"}, syncFlags...), syncFile)...)
+	var promptErr bytes.Buffer
+	prompt.Stderr = &promptErr
+	require.NoError(t, prompt.Run())
+	assert.Contains(t, promptErr.String(), "+ "+RECIPIENT_ADDRESS+"\n")
+	assert.Contains(t, promptErr.String(), "Apply these changes?")
+	assert.Empty(t, server.Whitelist(policyUUID, sponsorclient.ToAccountWhitelist))
+	var plan whitelistsync.Plan
+	run(0, &plan, append(append([]string{"sync", "-yes", "-o", "json"}, syncFlags...), syncFile)...)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, []string{RECIPIENT_ADDRESS}, server.Whitelist(policyUUID, sponsorclient.ToAccountWhitelist))
+	assert.Contains(t, run(0, nil, append(append([]string{"sync", "-yes"}, syncFlags...), syncFile)...), "No changes")
+
+	var sponsorable paymasterclient.IsSponsorableResponse
+	run(0, &sponsorable, append([]string{"sponsorable", "-from", from, "-to", RECIPIENT_ADDRESS}, paymasterFlags...)...)
+	assert.True(t, sponsorable.Sponsorable)
+
+	var sent paymasterclient.GaslessResult
+	run(0, &sent, append([]string{"send", "-to", RECIPIENT_ADDRESS, "-value", "1"}, paymasterFlags...)...)
+	require.NotNil(t, sent.Transaction)
+	txHash := sent.TxHash
+	assert.Equal(t, sent.Transaction.Hash(), txHash)
+
+	var tx paymasterclient.TransactionResponse
+	run(0, &tx, append(append([]string{"status"}, paymasterFlags...), txHash.Hex())...)
+	assert.Equal(t, paymasterclient.StatusNew, tx.Status)
+	assert.Equal(t, common.HexToAddress(from), tx.FromAddress)
+
+	server.ConfirmAll()
+	var bundle struct {
+		Bundle       paymasterclient.Bundle                `json:"bundle"`
+		Transactions []paymasterclient.TransactionResponse `json:"transactions"`
+	}
+	run(1, nil, append(append([]string{"bundle"}, paymasterFlags...), tx.BundleUUID.String())...)
+	run(0, &bundle, append(append([]string{"bundle", "-rpc-url", server.URL}, paymasterFlags...), tx.BundleUUID.String())...)
+	assert.Equal(t, paymasterclient.StatusConfirmed, bundle.Bundle.Status)
+	require.Len(t, bundle.Transactions, 1)
+	assert.Equal(t, txHash, bundle.Transactions[0].TxHash)
+
+	var spend sponsorclient.UserSpendData
+	run(0, &spend, append(append([]string{"spend", "user"}, sponsorFlags...), from)...)
+	assert.Equal(t, common.HexToAddress(from), spend.UserAddress)
+	run(1, nil, append(append([]string{"spend", "user"}, sponsorFlags...), RECIPIENT_ADDRESS)...)
+	run(2, nil, append([]string{"spend", "user"}, sponsorFlags...)...)
+}
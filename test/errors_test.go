@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+// startErrorServer starts a server failing every call with the given HTTP status or, for a 200 status,
+// with the given JSON-RPC error. It returns the URL and a func reporting the last request ID received.
+func startErrorServer(t *testing.T, status int, rpcErr map[string]interface{}) (string, func() string) {
+	t.Helper()
+
+	var lastRequestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequestID = r.Header.Get(mferrors.RequestIDHeader)
+		if status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": rpcErr})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func() string { return lastRequestID }
+}
+
+// TestTypedErrors checks the classification of HTTP and JSON-RPC failures.
+func TestTypedErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		rpcErr    map[string]interface{}
+		kind      error
+		retryable bool
+	}{
+		{"rate limited", http.StatusTooManyRequests, nil, mferrors.ErrRateLimited, true},
+		{"unauthorized", http.StatusUnauthorized, nil, mferrors.ErrUnauthorized, false},
+		{"bad gateway", http.StatusBadGateway, nil, mferrors.ErrUnavailable, true},
+		{"method not found", http.StatusOK, map[string]interface{}{"code": -32601, "message": "the method pm_foo does not exist"}, mferrors.ErrMethodNotFound, false},
+		{"limit exceeded", http.StatusOK, map[string]interface{}{"code": -32005, "message": "limit exceeded"}, mferrors.ErrRateLimited, true},
+		{"nonce too low", http.StatusOK, map[string]interface{}{"code": -32000, "message": "nonce too low"}, mferrors.ErrNonceTooLow, false},
+		{"not sponsorable", http.StatusOK, map[string]interface{}{"code": -32000, "message": "tx is not sponsorable"}, mferrors.ErrNotSponsorable, false},
+		{"policy exhausted in data", http.StatusOK, map[string]interface{}{"code": -32000, "message": "rejected", "data": "policy exhausted"}, mferrors.ErrPolicyExhausted, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			url, lastRequestID := startErrorServer(t, c.status, c.rpcErr)
+			client, err := paymasterclient.New(context.Background(), url)
+			require.NoError(t, err)
+
+			_, err = client.GetGaslessTransactionByHash(context.Background(), common.Hash{})
+			require.Error(t, err)
+			assert.ErrorIs(t, err, c.kind)
+			assert.Equal(t, c.retryable, mferrors.IsRetryable(err))
+
+			var mfErr *mferrors.Error
+			require.True(t, errors.As(err, &mfErr))
+			assert.Equal(t, "eth_getGaslessTransactionByHash", mfErr.Method)
+			assert.NotEmpty(t, mfErr.RequestID)
+			assert.Equal(t, lastRequestID(), mfErr.RequestID)
+
+			if c.status != http.StatusOK {
+				var httpErr rpc.HTTPError
+				require.True(t, errors.As(err, &httpErr))
+				assert.Equal(t, c.status, httpErr.StatusCode)
+				assert.Equal(t, c.status, mfErr.HTTPStatus)
+			} else {
+				assert.Equal(t, c.rpcErr["code"], mfErr.Code)
+			}
+		})
+	}
+}
+
+// TestSponsorTypedErrors checks that the sponsor client reports typed errors as well.
+func TestSponsorTypedErrors(t *testing.T) {
+	url, _ := startErrorServer(t, http.StatusOK, map[string]interface{}{"code": -32000, "message": "invalid whitelist type: Foo"})
+	client, err := sponsorclient.New(context.Background(), url)
+	require.NoError(t, err)
+
+	_, err = client.AddToWhitelist(context.Background(), sponsorclient.WhiteListArgs{
+		PolicyUUID:    uuid.Must(uuid.FromString(POLICY_UUID)),
+		WhitelistType: "Foo",
+		Values:        []string{RECIPIENT_ADDRESS},
+	})
+	require.ErrorIs(t, err, mferrors.ErrInvalidWhitelistType)
+	var mfErr *mferrors.Error
+	require.True(t, errors.As(err, &mfErr))
+	assert.Equal(t, "pm_addToWhitelist", mfErr.Method)
+	assert.False(t, mfErr.Retryable)
+}
+
+// TestErrorClass checks the metric label names of errors.
+func TestErrorClass(t *testing.T) {
+	assert.Equal(t, "ok", mferrors.Class(nil))
+	assert.Equal(t, "nonce_too_low", mferrors.Class(&mferrors.Error{Method: "eth_sendRawTransaction", Kind: mferrors.ErrNonceTooLow}))
+	assert.Equal(t, "invalid_params", mferrors.Class(fmt.Errorf("chunk 2: %w", &mferrors.Error{Kind: mferrors.ErrInvalidParams})))
+	assert.Equal(t, "not_found", mferrors.Class(mferrors.ErrNotFound))
+	assert.Equal(t, "canceled", mferrors.Class(&mferrors.Error{Method: "eth_chainId", Err: context.Canceled}))
+	assert.Equal(t, "timeout", mferrors.Class(context.DeadlineExceeded))
+	assert.Equal(t, "unknown", mferrors.Class(errors.New("boom")))
+}
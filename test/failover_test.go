@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// signedTransfer returns a signed zero gas price transfer for chain 97.
+func signedTransfer(t *testing.T, nonce uint64) []byte {
+	t.Helper()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+	tx, err := types.SignNewTx(privateKey, types.LatestSignerForChainID(big.NewInt(97)), &types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: big.NewInt(0),
+		Gas:      21000,
+		To:       &toAddress,
+		Value:    big.NewInt(0),
+	})
+	require.NoError(t, err)
+	input, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	return input
+}
+
+// TestFailoverReads checks that reads fail over to the next endpoint and unhealthy endpoints are skipped.
+func TestFailoverReads(t *testing.T) {
+	primary, primaryClient := startMegaFuel(t, nil)
+	secondary, secondaryClient := startMegaFuel(t, nil)
+	client, err := paymasterclient.NewFailoverClient(context.Background(), []paymasterclient.Endpoint{
+		{Name: "primary", Client: primaryClient},
+		{Name: "secondary", Client: secondaryClient},
+	}, &paymasterclient.FailoverOptions{HealthCheckInterval: -1, ChainID: big.NewInt(97)})
+	require.NoError(t, err)
+	defer client.Close()
+
+	primary.SetHTTPStatus(http.StatusServiceUnavailable)
+	blockNumber := rpc.PendingBlockNumber
+	before := len(secondary.Requests())
+	_, err = client.GetTransactionCount(context.Background(), common.HexToAddress(RECIPIENT_ADDRESS), rpc.BlockNumberOrHash{BlockNumber: &blockNumber})
+	require.NoError(t, err)
+	assert.Equal(t, before+1, len(secondary.Requests()))
+
+	health := client.Health()
+	assert.False(t, health[0].Healthy)
+	assert.ErrorIs(t, health[0].LastError, mferrors.ErrUnavailable)
+	assert.True(t, health[1].Healthy)
+
+	// The unhealthy primary is not tried until a health check brings it back.
+	primaryBefore := len(primary.Requests())
+	_, err = client.ChainID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, primaryBefore, len(primary.Requests()))
+
+	primary.SetHTTPStatus(0)
+	client.CheckHealth(context.Background())
+	assert.True(t, client.Health()[0].Healthy)
+}
+
+// TestFailoverChainIDMismatch checks that an endpoint of another chain is unhealthy.
+func TestFailoverChainIDMismatch(t *testing.T) {
+	_, mainnet := startMegaFuel(t, &megafueltest.Options{ChainID: 56})
+	_, testnet := startMegaFuel(t, nil)
+	client, err := paymasterclient.NewFailoverClient(context.Background(), []paymasterclient.Endpoint{
+		{Name: "mainnet", Client: mainnet},
+		{Name: "testnet", Client: testnet},
+	}, &paymasterclient.FailoverOptions{HealthCheckInterval: -1, ChainID: big.NewInt(97)})
+	require.NoError(t, err)
+	defer client.Close()
+
+	health := client.Health()
+	assert.False(t, health[0].Healthy)
+	assert.True(t, health[1].Healthy)
+}
+
+// TestFailoverSends checks that a transaction is never sent to two endpoints.
+func TestFailoverSends(t *testing.T) {
+	primary, primaryClient := startMegaFuel(t, nil)
+	secondary, secondaryClient := startMegaFuel(t, nil)
+	client, err := paymasterclient.NewFailoverClient(context.Background(), []paymasterclient.Endpoint{
+		{Name: "primary", Client: primaryClient},
+		{Name: "secondary", Client: secondaryClient},
+	}, &paymasterclient.FailoverOptions{HealthCheckInterval: -1})
+	require.NoError(t, err)
+	defer client.Close()
+
+	// A 503 may have been processed, so the send is not repeated on the secondary.
+	primary.SetHTTPStatus(http.StatusServiceUnavailable)
+	_, err = client.SendRawTransaction(context.Background(), signedTransfer(t, 0), nil)
+	require.ErrorIs(t, err, mferrors.ErrUnavailable)
+	assert.Empty(t, secondary.Transactions())
+
+	// An endpoint that cannot be reached never got the transaction, so the secondary gets it.
+	primary.SetHTTPStatus(0)
+	client.CheckHealth(context.Background())
+	primary.Close()
+
+	input := signedTransfer(t, 0)
+	txHash, err := client.SendRawTransaction(context.Background(), input, nil)
+	require.NoError(t, err)
+	assert.Empty(t, primary.Transactions())
+	require.Len(t, secondary.Transactions(), 1)
+
+	// Sending it again goes to the same endpoint, which reports the duplicate.
+	_, err = client.SendRawTransaction(context.Background(), input, nil)
+	require.ErrorIs(t, err, mferrors.ErrAlreadyKnown)
+	assert.Len(t, secondary.Transactions(), 1)
+
+	tx, err := client.GetGaslessTransactionByHash(context.Background(), txHash)
+	require.NoError(t, err)
+	assert.Equal(t, txHash, tx.TxHash)
+}
+
+// TestFailoverConcurrentSends sends the same transaction from many goroutines while the primary rejects it
+// unprocessed, and checks that only the secondary gets it and lookups by hash follow it there.
+func TestFailoverConcurrentSends(t *testing.T) {
+	primary, primaryClient := startMegaFuel(t, nil)
+	secondary, secondaryClient := startMegaFuel(t, nil)
+	client, err := paymasterclient.NewFailoverClient(context.Background(), []paymasterclient.Endpoint{
+		{Name: "primary", Client: primaryClient},
+		{Name: "secondary", Client: secondaryClient},
+	}, &paymasterclient.FailoverOptions{HealthCheckInterval: -1})
+	require.NoError(t, err)
+	defer client.Close()
+
+	// A rate limited send was not processed, so it fails over.
+	primary.SetHTTPStatus(http.StatusTooManyRequests)
+	input := signedTransfer(t, 0)
+	const sends = 10
+	var (
+		wg     sync.WaitGroup
+		hashes = make([]common.Hash, sends)
+		errs   = make([]error, sends)
+	)
+	for i := 0; i < sends; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hashes[i], errs[i] = client.SendRawTransaction(context.Background(), input, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	var txHash common.Hash
+	accepted := 0
+	for i, err := range errs {
+		if err == nil {
+			accepted++
+			txHash = hashes[i]
+		} else {
+			assert.ErrorIs(t, err, mferrors.ErrAlreadyKnown)
+		}
+	}
+	assert.Equal(t, 1, accepted)
+	assert.Empty(t, primary.Transactions())
+	assert.Len(t, secondary.Transactions(), 1)
+
+	// With the primary healthy again, lookups by hash still go to the secondary.
+	primary.SetHTTPStatus(0)
+	client.CheckHealth(context.Background())
+	primaryBefore := len(primary.Requests())
+	_, err = client.GetGaslessTransactionByHash(context.Background(), txHash)
+	require.NoError(t, err)
+	// The fake server only knows sponsor transactions by their own hash, the secondary answers not found.
+	_, err = client.GetSponsorTxByTxHash(context.Background(), txHash)
+	require.ErrorIs(t, err, mferrors.ErrNotFound)
+	assert.Equal(t, primaryBefore, len(primary.Requests()))
+}
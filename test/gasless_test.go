@@ -0,0 +1,62 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestGaslessSenderSend sends consecutive gasless transactions through a fake MegaFuel server.
+func TestGaslessSenderSend(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(client, paymasterclient.NewPrivateKeySigner(privateKey), nil)
+
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+	for i := 0; i < 2; i++ {
+		result, err := sender.Send(context.Background(), paymasterclient.GaslessRequest{
+			To:    &toAddress,
+			Value: big.NewInt(1),
+		})
+		require.NoError(t, err, "Failed to send gasless transaction")
+		assert.Equal(t, result.Transaction.Hash(), result.TxHash)
+		assert.Equal(t, uint64(i), result.Transaction.Nonce())
+		assert.Zero(t, result.Transaction.GasPrice().Sign())
+		assert.Equal(t, "megafueltest", result.Sponsor.SponsorName)
+		assert.Equal(t, paymasterclient.ReasonNone, result.Reason)
+	}
+	assert.Len(t, server.Transactions(), 2)
+}
+
+// TestGaslessSenderNotSponsorable checks that unsponsorable transactions are never sent.
+func TestGaslessSenderNotSponsorable(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+	server.SetSponsorable(func(uuid.UUID, paymasterclient.TransactionArgs) (*paymasterclient.IsSponsorableResponse, error) {
+		return &paymasterclient.IsSponsorableResponse{Sponsorable: false}, nil
+	})
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(client, paymasterclient.NewPrivateKeySigner(privateKey), nil)
+
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+	result, err := sender.Send(context.Background(), paymasterclient.GaslessRequest{To: &toAddress})
+	require.ErrorIs(t, err, paymasterclient.ErrNotSponsorable)
+	require.NotNil(t, result)
+	assert.Equal(t, paymasterclient.ReasonRejectedByPolicy, result.Reason)
+	assert.False(t, result.Sponsor.Sponsorable)
+	assert.Empty(t, server.Transactions())
+
+	_, err = sender.Send(context.Background(), paymasterclient.GaslessRequest{To: &toAddress, Data: []byte{0x01}})
+	assert.ErrorIs(t, err, paymasterclient.ErrGasRequired)
+}
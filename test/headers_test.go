@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// headerRecord is a JSON-RPC call seen by the header recording server.
+type headerRecord struct {
+	method     string
+	params     json.RawMessage
+	userAgent  string
+	policyUUID string
+}
+
+// startHeaderRecorder starts a JSON-RPC server that records the headers of every call and answers
+// eth_sendRawTransaction with a zero hash, pm_isSponsorable with a sponsorable response and eth_chainId with 97.
+func startHeaderRecorder(t *testing.T) (*httptest.Server, func() []headerRecord) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		records []headerRecord
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		records = append(records, headerRecord{
+			method:     req.Method,
+			params:     req.Params,
+			userAgent:  r.Header.Get("User-Agent"),
+			policyUUID: r.Header.Get("X-MegaFuel-Policy-Uuid"),
+		})
+		mu.Unlock()
+
+		var result interface{} = common.Hash{}
+		switch req.Method {
+		case "eth_chainId":
+			result = "0x61"
+		case "pm_isSponsorable":
+			result = paymasterclient.IsSponsorableResponse{Sponsorable: true}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []headerRecord {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]headerRecord(nil), records...)
+	}
+}
+
+// TestPerCallHeadersDoNotLeak checks that a User-Agent set for one call is not sent with later calls.
+func TestPerCallHeadersDoNotLeak(t *testing.T) {
+	server, records := startHeaderRecorder(t)
+	client, err := paymasterclient.NewPrivatePaymaster(context.Background(), server.URL, PRIVATE_POLICY)
+	require.NoError(t, err)
+
+	_, err = client.SendRawTransaction(context.Background(), hexutil.Bytes{0x01}, &paymasterclient.TransactionOptions{UserAgent: "first"})
+	require.NoError(t, err)
+	_, err = client.SendRawTransaction(context.Background(), hexutil.Bytes{0x02}, nil)
+	require.NoError(t, err)
+	_, err = client.IsSponsorable(paymasterclient.WithPolicyUUID(context.Background(), POLICY_UUID), paymasterclient.TransactionArgs{})
+	require.NoError(t, err)
+	_, err = client.ChainID(context.Background())
+	require.NoError(t, err)
+
+	got := records()
+	require.Len(t, got, 4)
+	assert.Equal(t, "first", got[0].userAgent)
+	assert.Equal(t, PRIVATE_POLICY, got[0].policyUUID)
+	assert.NotEqual(t, "first", got[1].userAgent)
+	assert.Equal(t, PRIVATE_POLICY, got[1].policyUUID)
+	assert.Equal(t, POLICY_UUID, got[2].policyUUID)
+	assert.Empty(t, got[3].policyUUID)
+}
+
+// TestConcurrentPerCallHeaders shares one client between many goroutines that each use their own
+// User-Agent and policy. Run with -race.
+func TestConcurrentPerCallHeaders(t *testing.T) {
+	server, records := startHeaderRecorder(t)
+	client, err := paymasterclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	const workers = 32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := paymasterclient.WithPolicyUUID(context.Background(), fmt.Sprintf("policy-%d", i))
+			_, err := client.SendRawTransaction(ctx, hexutil.Bytes{byte(i)}, &paymasterclient.TransactionOptions{UserAgent: fmt.Sprintf("agent-%d", i)})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	got := records()
+	require.Len(t, got, workers)
+	for _, record := range got {
+		var params []hexutil.Bytes
+		require.NoError(t, json.Unmarshal(record.params, &params))
+		require.Len(t, params, 1)
+		i := int(params[0][0])
+		assert.Equal(t, fmt.Sprintf("agent-%d", i), record.userAgent)
+		assert.Equal(t, fmt.Sprintf("policy-%d", i), record.policyUUID)
+	}
+}
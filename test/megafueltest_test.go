@@ -0,0 +1,159 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+// startMegaFuel starts a fake MegaFuel server for the duration of the test and returns a paymaster client connected to it.
+func startMegaFuel(t *testing.T, opts *megafueltest.Options) (*megafueltest.Server, paymasterclient.Client) {
+	t.Helper()
+
+	server := megafueltest.NewServer(opts)
+	t.Cleanup(server.Close)
+	client, err := paymasterclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	return server, client
+}
+
+// TestMegaFuelTestWhitelists manages whitelists through the sponsor API and checks that they gate sponsorship.
+func TestMegaFuelTestWhitelists(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, paymaster := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(paymaster, paymasterclient.NewPrivateKeySigner(privateKey), nil)
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+
+	_, err = sponsor.AddToWhitelist(ctx, sponsorclient.WhiteListArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.FromAccountWhitelist,
+		Values:        []string{common.HexToAddress("0x01").Hex(), sender.Address().Hex()},
+	})
+	require.NoError(t, err)
+	assert.Len(t, server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist), 2)
+
+	page, err := sponsor.GetWhitelist(ctx, sponsorclient.GetWhitelistArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.FromAccountWhitelist,
+		Offset:        1,
+		Limit:         1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{sender.Address().Hex()}, page)
+
+	_, err = sender.Send(ctx, paymasterclient.GaslessRequest{To: &toAddress})
+	require.NoError(t, err)
+
+	_, err = sponsor.RmFromWhitelist(ctx, sponsorclient.WhiteListArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.FromAccountWhitelist,
+		Values:        []string{sender.Address().Hex()},
+	})
+	require.NoError(t, err)
+	_, err = sender.Send(ctx, paymasterclient.GaslessRequest{To: &toAddress})
+	require.ErrorIs(t, err, paymasterclient.ErrNotSponsorable)
+
+	_, err = sponsor.EmptyWhitelist(ctx, sponsorclient.EmptyWhiteListArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.FromAccountWhitelist})
+	require.NoError(t, err)
+	assert.Empty(t, server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist))
+
+	_, err = sponsor.EmptyWhitelist(ctx, sponsorclient.EmptyWhiteListArgs{PolicyUUID: policyUUID, WhitelistType: "Unknown"})
+	require.ErrorIs(t, err, mferrors.ErrInvalidWhitelistType)
+
+	// Only the accepted transaction is accounted.
+	policy, err := sponsor.GetPolicySpendData(ctx, policyUUID)
+	require.NoError(t, err)
+	user, err := sponsor.GetUserSpendData(ctx, sender.Address(), policyUUID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), user.TxCountCurDay)
+	assert.Equal(t, policy.Cost.Raw(), user.GasCost.Raw())
+	assert.Positive(t, policy.Cost.Raw().Sign())
+}
+
+// TestMegaFuelTestStatuses moves a bundle through its statuses and checks the bundle and sponsor transaction follow.
+func TestMegaFuelTestStatuses(t *testing.T) {
+	server, client := startMegaFuel(t, &megafueltest.Options{BundleSize: 2})
+	ctx := context.Background()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(client, paymasterclient.NewPrivateKeySigner(privateKey), nil)
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+
+	var txHashes []common.Hash
+	for i := 0; i < 2; i++ {
+		result, err := sender.Send(ctx, paymasterclient.GaslessRequest{To: &toAddress})
+		require.NoError(t, err)
+		txHashes = append(txHashes, result.TxHash)
+	}
+
+	tx, err := client.GetGaslessTransactionByHash(ctx, txHashes[0])
+	require.NoError(t, err)
+	assert.Equal(t, paymasterclient.StatusNew, tx.Status)
+	second, err := client.GetGaslessTransactionByHash(ctx, txHashes[1])
+	require.NoError(t, err)
+	assert.Equal(t, tx.BundleUUID, second.BundleUUID)
+
+	require.NoError(t, server.SetStatus(txHashes[0], paymasterclient.StatusPending))
+	sponsorTx, err := client.GetSponsorTxByBundleUUID(ctx, tx.BundleUUID)
+	require.NoError(t, err)
+	assert.Equal(t, paymasterclient.StatusPending, sponsorTx.Status)
+	assert.Equal(t, megafueltest.SponsorAddress, sponsorTx.Address)
+
+	server.ConfirmAll()
+	bundle, err := client.GetBundleByUUID(ctx, tx.BundleUUID)
+	require.NoError(t, err)
+	assert.Equal(t, paymasterclient.StatusConfirmed, bundle.Status)
+	assert.NotZero(t, bundle.ConfirmedBlockNumber)
+
+	sponsorTx, err = client.GetSponsorTxByTxHash(ctx, sponsorTx.TxHash)
+	require.NoError(t, err)
+	assert.Equal(t, paymasterclient.StatusConfirmed, sponsorTx.Status)
+	assert.Positive(t, sponsorTx.GasFee.Raw().Sign())
+
+	tx, err = client.GetGaslessTransactionByHash(ctx, txHashes[1])
+	require.NoError(t, err)
+	assert.Equal(t, paymasterclient.StatusConfirmed, tx.Status)
+	assert.Equal(t, uint64(21000), tx.GasUsed)
+
+	// Like the real API, the server sends statuses as numbers.
+	raw, err := rpc.DialContext(ctx, server.URL)
+	require.NoError(t, err)
+	defer raw.Close()
+	var response map[string]json.RawMessage
+	require.NoError(t, raw.CallContext(ctx, &response, "eth_getGaslessTransactionByHash", txHashes[1]))
+	assert.Equal(t, "2", string(response["status"]))
+
+	require.Error(t, server.SetStatus(common.HexToHash("0x01"), paymasterclient.StatusConfirmed))
+}
+
+// TestMegaFuelTestFailNext checks that scripted failures are returned once, in order.
+func TestMegaFuelTestFailNext(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+
+	server.FailNext("eth_chainId", &megafueltest.Error{Code: mferrors.CodeLimitExceeded, Message: "limit exceeded"})
+	_, err := client.ChainID(context.Background())
+	require.ErrorIs(t, err, mferrors.ErrRateLimited)
+
+	chainID, err := client.ChainID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, server.ChainID(), chainID)
+}
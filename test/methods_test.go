@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+const tokenABI = `[
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"type":"bool"}]},
+	{"type":"function","name":"approve","inputs":[{"name":"spender","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"type":"bool"}]},
+	{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]}
+]`
+
+// TestMethodSet resolves method names, signatures and selectors and maps selectors back to signatures.
+func TestMethodSet(t *testing.T) {
+	set, err := sponsorclient.ParseMethodSet(tokenABI)
+	require.NoError(t, err)
+
+	selectors, err := set.Selectors("transfer", "approve(address, uint256)", "0x23B872DD", "safeTransferFrom(address,address,uint256,bytes)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xa9059cbb", "0x095ea7b3", "0x23b872dd", "0xb88d4fde"}, selectors)
+
+	_, err = set.Selectors("safeTransferFrom")
+	assert.ErrorContains(t, err, "overloaded")
+	_, err = set.Selectors("mint")
+	assert.ErrorContains(t, err, "unknown method")
+	_, err = set.Selectors("transfer(address,uint)")
+	assert.Error(t, err)
+	_, err = set.Selectors("Transfer")
+	assert.Error(t, err, "events are not methods")
+
+	sig, ok := set.Signature("0xA9059CBB")
+	assert.True(t, ok)
+	assert.Equal(t, "transfer(address,uint256)", sig)
+	_, ok = set.Signature("0x23b872dd")
+	assert.False(t, ok)
+	require.NoError(t, set.AddSignatures("transferFrom(address,address,uint256)"))
+	sig, ok = set.Signature("0x23b872dd")
+	assert.True(t, ok)
+	assert.Equal(t, "transferFrom(address,address,uint256)", sig)
+
+	var nilSet *sponsorclient.MethodSet
+	selectors, err = nilSet.Selectors("transfer(address,uint256)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xa9059cbb"}, selectors)
+	_, err = nilSet.Selectors("transfer")
+	assert.Error(t, err)
+
+	_, err = sponsorclient.ParseMethodSet("not json")
+	assert.Error(t, err)
+}
+
+// TestContractMethods manages the ContractMethodSigWhitelist of a policy from a contract ABI.
+func TestContractMethods(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	set, err := sponsorclient.ParseMethodSet(tokenABI)
+	require.NoError(t, err)
+	chunks := &sponsorclient.ChunkOptions{ChunkSize: 100}
+
+	_, err = sponsorclient.AddContractMethods(ctx, sponsor, policyUUID, set, chunks, "transfer", "approve", "0x12345678")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"0xa9059cbb", "0x095ea7b3", "0x12345678"}, server.Whitelist(policyUUID, sponsorclient.ContractMethodSigWhitelist))
+
+	_, err = sponsorclient.AddContractMethods(ctx, sponsor, policyUUID, set, chunks, "transfer", "mint")
+	assert.ErrorContains(t, err, "mint")
+	assert.Len(t, server.Whitelist(policyUUID, sponsorclient.ContractMethodSigWhitelist), 3)
+
+	_, err = sponsorclient.RmContractMethods(ctx, sponsor, policyUUID, set, chunks, "approve(address,uint256)")
+	require.NoError(t, err)
+
+	methods, err := sponsorclient.ListContractMethods(ctx, sponsor, policyUUID, set)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []sponsorclient.ContractMethod{
+		{Selector: "0xa9059cbb", Signature: "transfer(address,uint256)"},
+		{Selector: "0x12345678"},
+	}, methods)
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gofrs/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/mfmetrics"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+// TestMetrics records calls, sponsorable answers and final statuses on a private registry.
+func TestMetrics(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, raw := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	rawSponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	reg := prometheus.NewRegistry()
+	metrics, err := mfmetrics.New(reg, nil)
+	require.NoError(t, err)
+	client, sponsor := metrics.Paymaster(raw), metrics.Sponsor(rawSponsor)
+
+	// The collectors are registered once per registerer, and never globally.
+	_, err = mfmetrics.New(reg, nil)
+	assert.Error(t, err)
+	count, err := testutil.GatherAndCount(prometheus.DefaultGatherer, "megafuel_client_requests_total")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	server.SetSponsorable(func(_ uuid.UUID, tx paymasterclient.TransactionArgs) (*paymasterclient.IsSponsorableResponse, error) {
+		return &paymasterclient.IsSponsorableResponse{Sponsorable: tx.Nonce != nil && *tx.Nonce == 0}, nil
+	})
+	nonce := func(n uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&n) }
+	to := common.HexToAddress(RECIPIENT_ADDRESS)
+	_, err = client.IsSponsorable(ctx, paymasterclient.TransactionArgs{To: &to, Nonce: nonce(0)})
+	require.NoError(t, err)
+	results, err := client.BatchIsSponsorable(ctx, []paymasterclient.TransactionArgs{{To: &to, Nonce: nonce(0)}, {To: &to, Nonce: nonce(1)}})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 2.0, metricValue(t, reg, "megafuel_sponsorable_checks_total", "true"))
+	assert.Equal(t, 1.0, metricValue(t, reg, "megafuel_sponsorable_checks_total", "false"))
+	server.SetSponsorable(nil)
+
+	// Sends are counted by final status once a lookup sees it, and only once.
+	hash, err := client.SendRawTransaction(ctx, signedTransfer(t, 0), nil)
+	require.NoError(t, err)
+	tx, err := client.GetGaslessTransactionByHash(ctx, hash)
+	require.NoError(t, err)
+	require.False(t, tx.Status.IsTerminal())
+	server.ConfirmAll()
+	_, err = client.BatchGetGaslessTransactions(ctx, []common.Hash{hash})
+	require.NoError(t, err)
+	_, err = client.GetGaslessTransactionByHash(ctx, hash)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metricValue(t, reg, "megafuel_transactions_final_status_total", paymasterclient.StatusConfirmed.String()))
+
+	// Failures are counted by error class.
+	server.FailNext("eth_sendRawTransaction", &megafueltest.Error{Code: -32000, Message: "nonce too low"})
+	_, err = client.SendRawTransaction(ctx, signedTransfer(t, 0), nil)
+	require.ErrorIs(t, err, mferrors.ErrNonceTooLow)
+	_, err = sponsor.GetPolicySpendData(ctx, uuid.Must(uuid.NewV4()))
+	require.ErrorIs(t, err, mferrors.ErrNotFound)
+
+	assert.Equal(t, 3.0, metricValue(t, reg, "megafuel_client_requests_total", "ok", "paymaster", "pm_isSponsorable"))
+	assert.Equal(t, 1.0, metricValue(t, reg, "megafuel_client_requests_total", "ok", "paymaster", "eth_sendRawTransaction"))
+	assert.Equal(t, 1.0, metricValue(t, reg, "megafuel_client_requests_total", "nonce_too_low", "paymaster", "eth_sendRawTransaction"))
+	assert.Equal(t, 3.0, metricValue(t, reg, "megafuel_client_requests_total", "ok", "paymaster", "eth_getGaslessTransactionByHash"))
+	assert.Equal(t, 1.0, metricValue(t, reg, "megafuel_client_requests_total", "not_found", "sponsor", "pm_getPolicySpendData"))
+	// The latency of a batch is observed once.
+	assert.Equal(t, 2.0, metricValue(t, reg, "megafuel_client_request_duration_seconds", "paymaster", "pm_isSponsorable"))
+}
+
+// metricValue returns the value of the counter, or the sample count of the histogram, of family name whose
+// label values are values, in label name order.
+func metricValue(t *testing.T, reg *prometheus.Registry, name string, values ...string) float64 {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			labels := metric.GetLabel()
+			if len(labels) != len(values) {
+				continue
+			}
+			for i, label := range labels {
+				if label.GetValue() != values[i] {
+					continue metrics
+				}
+			}
+			if histogram := metric.GetHistogram(); histogram != nil {
+				return float64(histogram.GetSampleCount())
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	t.Fatalf("no %s%q", name, values)
+	return 0
+}
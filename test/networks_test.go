@@ -0,0 +1,45 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestNetworkPresets checks the preset lookups and URL templates.
+func TestNetworkPresets(t *testing.T) {
+	network, ok := networks.ByChainID(97)
+	require.True(t, ok)
+	assert.Equal(t, networks.BSCTestnet, network)
+
+	network, ok = networks.ByName("opbnb-mainnet")
+	require.True(t, ok)
+	assert.Equal(t, int64(204), network.ChainID)
+
+	assert.Equal(t, "https://open-platform-ap.nodereal.io/my-key/megafuel-testnet", networks.BSCTestnet.SponsorURL("my-key"))
+	assert.Equal(t, "https://open-platform-ap.nodereal.io/my-key/megafuel-testnet/97", networks.BSCTestnet.PrivatePaymasterURL("my-key"))
+
+	_, ok = networks.ByName("goerli")
+	assert.False(t, ok)
+}
+
+// TestNewForNetworkVerifiesChainID checks that a client is refused when the endpoint serves another chain.
+func TestNewForNetworkVerifiesChainID(t *testing.T) {
+	server, _ := startMegaFuel(t, nil)
+
+	testnet := networks.BSCTestnet
+	testnet.PaymasterURL = server.URL
+	client, err := paymasterclient.NewForNetwork(context.Background(), testnet)
+	require.NoError(t, err)
+	assert.NotNil(t, client)
+
+	mainnet := networks.BSCMainnet
+	mainnet.PaymasterURL = server.URL
+	_, err = paymasterclient.NewForNetwork(context.Background(), mainnet)
+	require.ErrorIs(t, err, networks.ErrChainIDMismatch)
+}
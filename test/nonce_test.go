@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestNonceManagerConcurrentSends sends from many goroutines while the paymaster reports a stale nonce.
+func TestNonceManagerConcurrentSends(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+	server.SetStaleNonces(true)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(client, paymasterclient.NewPrivateKeySigner(privateKey), &paymasterclient.GaslessSenderOptions{
+		NonceManager: paymasterclient.NewNonceManager(client),
+	})
+
+	const sends = 20
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+	var wg sync.WaitGroup
+	for i := 0; i < sends; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sender.Send(context.Background(), paymasterclient.GaslessRequest{To: &toAddress})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	nonces := make(map[uint64]bool)
+	for _, tx := range server.Transactions() {
+		nonces[tx.Nonce()] = true
+	}
+	assert.Len(t, nonces, sends)
+}
+
+// TestNonceManagerReleaseAndResync checks that unused nonces are reclaimed and nonce errors resync the account.
+func TestNonceManagerReleaseAndResync(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+	manager := paymasterclient.NewNonceManager(client)
+	address := common.HexToAddress(RECIPIENT_ADDRESS)
+	ctx := context.Background()
+
+	server.SetNonce(address, 5)
+	for want := uint64(5); want < 9; want++ {
+		nonce, err := manager.Next(ctx, address)
+		require.NoError(t, err)
+		require.Equal(t, want, nonce)
+	}
+
+	// A released nonce in the middle is handed out again first, the top one moves next back.
+	manager.Release(address, 6)
+	manager.Release(address, 8)
+	nonce, err := manager.Next(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), nonce)
+	nonce, err = manager.Next(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), nonce)
+
+	// Nonce too low never hands out the failed nonce again, even if the paymaster lags behind.
+	require.NoError(t, manager.HandleSendError(ctx, address, 10, &mferrors.Error{Kind: mferrors.ErrNonceTooLow}))
+	nonce, err = manager.Next(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), nonce)
+
+	// A nonce gap resyncs with the paymaster.
+	require.NoError(t, manager.HandleSendError(ctx, address, 11, &mferrors.Error{Kind: mferrors.ErrNonceTooHigh}))
+	nonce, err = manager.Next(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), nonce)
+}
+
+// TestNonceManagerKeepsNonceOnUnknownOutcome checks that a nonce is only released when the send never reached the paymaster.
+func TestNonceManagerKeepsNonceOnUnknownOutcome(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+	manager := paymasterclient.NewNonceManager(client)
+	address := common.HexToAddress(RECIPIENT_ADDRESS)
+	ctx := context.Background()
+
+	server.SetNonce(address, 5)
+	nonce, err := manager.Next(ctx, address)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), nonce)
+
+	// The transaction is already in the pool, its nonce is in flight.
+	require.NoError(t, manager.HandleSendError(ctx, address, 5, &mferrors.Error{Kind: mferrors.ErrAlreadyKnown}))
+	nonce, err = manager.Next(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), nonce)
+
+	// A timeout may have happened after the paymaster accepted the transaction.
+	require.NoError(t, manager.HandleSendError(ctx, address, 6, mferrors.Wrap("eth_sendRawTransaction", "", context.DeadlineExceeded)))
+	nonce, err = manager.Next(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), nonce)
+
+	// A rate limited send was rejected before being processed.
+	require.NoError(t, manager.HandleSendError(ctx, address, 7, &mferrors.Error{Kind: mferrors.ErrRateLimited}))
+	nonce, err = manager.Next(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), nonce)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
 	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
 )
 
@@ -284,3 +285,21 @@ func TestPrivatePolicyGaslessTransaction(t *testing.T) {
 	require.NoError(t, err, "failed to GetTransactionCount")
 	assert.Greater(t, count, hexutil.Uint64(0))
 }
+
+// TestPaymasterForNetwork checks that the network preset constructors reach the testnet endpoints.
+func TestPaymasterForNetwork(t *testing.T) {
+	_, key, _, err := setupCommon(t)
+	require.NoError(t, err, "failed to set up paymaster")
+
+	paymasterClient, err := paymasterclient.NewForNetwork(context.Background(), networks.BSCTestnet)
+	require.NoError(t, err, "Failed to create PaymasterClient for the network")
+	chainID, err := paymasterClient.ChainID(context.Background())
+	require.NoError(t, err, "Failed to get the chain ID")
+	assert.Equal(t, networks.BSCTestnet.ChainID, chainID.Int64())
+
+	privateClient, err := paymasterclient.NewPrivatePaymasterForNetwork(context.Background(), networks.BSCTestnet, key, PRIVATE_POLICY)
+	require.NoError(t, err, "Failed to create Private PaymasterClient for the network")
+	chainID, err = privateClient.ChainID(context.Background())
+	require.NoError(t, err, "Failed to get the chain ID")
+	assert.Equal(t, networks.BSCTestnet.ChainID, chainID.Int64())
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/apikey"
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+const recorderAPIKey = "0123456789abcdef0123456789abcdef"
+
+// recordedSession sends a gasless transaction and reads back its whitelist through both clients.
+func recordedSession(t *testing.T, url string, recorder *megafueltest.Recorder, policyUUID uuid.UUID) (common.Hash, interface{}) {
+	t.Helper()
+	ctx := context.Background()
+
+	paymaster, err := paymasterclient.New(ctx, url+"/megafuel-testnet/97", recorder.ClientOption())
+	require.NoError(t, err)
+	sponsor, err := sponsorclient.New(ctx, url+"/megafuel-testnet", recorder.ClientOption())
+	require.NoError(t, err)
+
+	// A fixed key makes the signed transaction, and so the params of the calls, identical across runs.
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(paymaster, paymasterclient.NewPrivateKeySigner(privateKey), nil)
+
+	_, err = sponsor.AddToWhitelist(ctx, sponsorclient.WhiteListArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.FromAccountWhitelist,
+		Values:        []string{sender.Address().Hex()},
+	})
+	require.NoError(t, err)
+
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+	result, err := sender.Send(ctx, paymasterclient.GaslessRequest{To: &toAddress})
+	require.NoError(t, err)
+
+	whitelist, err := sponsor.GetWhitelist(ctx, sponsorclient.GetWhitelistArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.FromAccountWhitelist,
+	})
+	require.NoError(t, err)
+	return result.TxHash, whitelist
+}
+
+// TestRecorderRecordAndReplay records a session against a fake server and replays it once the server is gone.
+func TestRecorderRecordAndReplay(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server := megafueltest.NewServer(&megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	fixture := filepath.Join(t.TempDir(), "session.json")
+
+	recorder, err := megafueltest.NewRecorder(fixture, &megafueltest.RecorderOptions{Mode: megafueltest.ModeRecord})
+	require.NoError(t, err)
+	recordedHash, recordedWhitelist := recordedSession(t, server.URL+"/"+recorderAPIKey, recorder, policyUUID)
+	require.NoError(t, recorder.Save())
+	server.Close()
+
+	data, err := os.ReadFile(fixture)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), recorderAPIKey)
+	assert.Contains(t, string(data), "/{apikey}/megafuel-testnet")
+
+	replayer, err := megafueltest.NewRecorder(fixture, nil)
+	require.NoError(t, err)
+	txHash, whitelist := recordedSession(t, "http://megafuel.invalid/another-key", replayer, policyUUID)
+	assert.Equal(t, recordedHash, txHash)
+	assert.Equal(t, recordedWhitelist, whitelist)
+
+	// Calls that were not recorded fail.
+	client, err := paymasterclient.New(context.Background(), "http://megafuel.invalid", replayer.ClientOption())
+	require.NoError(t, err)
+	_, err = client.GetGaslessTransactionByHash(context.Background(), common.HexToHash("0x01"))
+	require.ErrorIs(t, err, megafueltest.ErrNoInteraction)
+}
+
+// TestRecorderWithCredential records through clients created with a credential, the recorder sitting below
+// apikey.Transport so that the key still goes into the path.
+func TestRecorderWithCredential(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server := megafueltest.NewServer(&megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	fixture := filepath.Join(t.TempDir(), "session.json")
+	cred := apikey.Static(recorderAPIKey)
+	ctx := context.Background()
+
+	session := func(url string, recorder *megafueltest.Recorder) interface{} {
+		t.Helper()
+		httpClient := &http.Client{Transport: apikey.NewTransport(cred, recorder.Transport(nil))}
+		paymaster, err := paymasterclient.NewPrivatePaymasterWithCredential(ctx, url+"/megafuel-testnet/97", cred, policyUUID.String(), rpc.WithHTTPClient(httpClient))
+		require.NoError(t, err)
+		chainID, err := paymaster.ChainID(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(97), chainID.Int64())
+
+		sponsor, err := sponsorclient.NewWithCredential(ctx, url+"/megafuel-testnet", cred, rpc.WithHTTPClient(httpClient))
+		require.NoError(t, err)
+		_, err = sponsor.AddToWhitelist(ctx, sponsorclient.WhiteListArgs{
+			PolicyUUID:    policyUUID,
+			WhitelistType: sponsorclient.ToAccountWhitelist,
+			Values:        []string{RECIPIENT_ADDRESS},
+		})
+		require.NoError(t, err)
+		whitelist, err := sponsor.GetWhitelist(ctx, sponsorclient.GetWhitelistArgs{
+			PolicyUUID:    policyUUID,
+			WhitelistType: sponsorclient.ToAccountWhitelist,
+		})
+		require.NoError(t, err)
+		return whitelist
+	}
+
+	recorder, err := megafueltest.NewRecorder(fixture, &megafueltest.RecorderOptions{Mode: megafueltest.ModeRecord})
+	require.NoError(t, err)
+	recordedWhitelist := session(server.URL+"/{apikey}", recorder)
+	require.NoError(t, recorder.Save())
+	for _, req := range server.Requests() {
+		assert.Contains(t, req.Path, "/"+recorderAPIKey+"/megafuel-testnet")
+	}
+	server.Close()
+
+	data, err := os.ReadFile(fixture)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), recorderAPIKey)
+	assert.Contains(t, string(data), "/{apikey}/megafuel-testnet")
+
+	replayer, err := megafueltest.NewRecorder(fixture, nil)
+	require.NoError(t, err)
+	assert.Equal(t, recordedWhitelist, session("http://megafuel.invalid/{apikey}", replayer))
+}
+
+// TestRecorderLargeNumbers replays calls whose params differ only beyond the precision of a float64.
+func TestRecorderLargeNumbers(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "numbers.json")
+	require.NoError(t, os.WriteFile(fixture, []byte(`{"interactions": [
+		{"url": "http://megafuel.invalid", "method": "test_echo", "params": [9007199254740992], "status": 200, "result": "even"},
+		{"url": "http://megafuel.invalid", "method": "test_echo", "params": [ 9007199254740993 ], "status": 200, "result": "odd"}
+	]}`), 0o644))
+	replayer, err := megafueltest.NewRecorder(fixture, nil)
+	require.NoError(t, err)
+	client, err := rpc.DialOptions(context.Background(), "http://megafuel.invalid", replayer.ClientOption())
+	require.NoError(t, err)
+	defer client.Close()
+
+	var result string
+	require.NoError(t, client.CallContext(context.Background(), &result, "test_echo", json.RawMessage("9007199254740993")))
+	assert.Equal(t, "odd", result)
+	require.NoError(t, client.CallContext(context.Background(), &result, "test_echo", json.RawMessage("9007199254740992")))
+	assert.Equal(t, "even", result)
+	err = client.CallContext(context.Background(), &result, "test_echo", json.RawMessage("9007199254740994"))
+	require.ErrorIs(t, err, megafueltest.ErrNoInteraction)
+}
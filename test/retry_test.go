@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/retry"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+// fastRetryPolicy keeps the retry tests quick.
+var fastRetryPolicy = &retry.Policy{
+	InitialInterval: time.Millisecond,
+	MaxInterval:     10 * time.Millisecond,
+	Multiplier:      2,
+	MaxElapsedTime:  5 * time.Second,
+}
+
+// startFlakyServer starts a server failing the first failures calls with status, setting retryAfter as
+// Retry-After header if not empty, and answering later calls with result. It returns the URL and the call counter.
+func startFlakyServer(t *testing.T, failures int64, status int, retryAfter string, result interface{}) (string, *atomic.Int64) {
+	t.Helper()
+
+	calls := new(atomic.Int64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, calls
+}
+
+// TestRetryReads checks that reads are retried on server errors.
+func TestRetryReads(t *testing.T) {
+	url, calls := startFlakyServer(t, 2, http.StatusBadGateway, "", "0x61")
+	client, err := paymasterclient.New(context.Background(), url)
+	require.NoError(t, err)
+
+	chainID, err := paymasterclient.NewRetryClient(client, fastRetryPolicy).ChainID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(97), chainID.Int64())
+	assert.Equal(t, int64(3), calls.Load())
+}
+
+// TestRetrySendOnlyWhenNotSent checks that a send is not repeated after a failure that may have been processed.
+func TestRetrySendOnlyWhenNotSent(t *testing.T) {
+	url, calls := startFlakyServer(t, 1, http.StatusBadGateway, "", common.Hash{})
+	client, err := paymasterclient.New(context.Background(), url)
+	require.NoError(t, err)
+
+	_, err = paymasterclient.NewRetryClient(client, fastRetryPolicy).SendRawTransaction(context.Background(), hexutil.Bytes{0x01}, nil)
+	require.ErrorIs(t, err, mferrors.ErrUnavailable)
+	assert.Equal(t, int64(1), calls.Load())
+
+	url, calls = startFlakyServer(t, 1, http.StatusTooManyRequests, "", common.Hash{})
+	client, err = paymasterclient.New(context.Background(), url)
+	require.NoError(t, err)
+
+	_, err = paymasterclient.NewRetryClient(client, fastRetryPolicy).SendRawTransaction(context.Background(), hexutil.Bytes{0x01}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), calls.Load())
+}
+
+// TestRetryHonoursRetryAfter checks that the delay requested by a 429 response is respected.
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	url, calls := startFlakyServer(t, 1, http.StatusTooManyRequests, "1", map[string]interface{}{"cost": "0x1"})
+	client, err := sponsorclient.New(context.Background(), url)
+	require.NoError(t, err)
+
+	start := time.Now()
+	spend, err := sponsorclient.NewRetryClient(client, fastRetryPolicy).GetPolicySpendData(context.Background(), uuid.Must(uuid.FromString(POLICY_UUID)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), spend.Cost.Raw().Int64())
+	assert.Equal(t, int64(2), calls.Load())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+// TestRetryGivesUp checks that the policy stops after MaxAttempts.
+func TestRetryGivesUp(t *testing.T) {
+	url, calls := startFlakyServer(t, 100, http.StatusServiceUnavailable, "", "0x61")
+	client, err := paymasterclient.New(context.Background(), url)
+	require.NoError(t, err)
+
+	policy := *fastRetryPolicy
+	policy.MaxAttempts = 4
+	_, err = paymasterclient.NewRetryClient(client, &policy).ChainID(context.Background())
+	require.ErrorIs(t, err, mferrors.ErrUnavailable)
+	assert.Equal(t, int64(4), calls.Load())
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestHDWalletSigner checks derivation against the well known vectors of the "abandon ... about" mnemonic.
+func TestHDWalletSigner(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	signer, err := paymasterclient.NewHDWalletSigner(mnemonic, "", paymasterclient.DefaultDerivationPath)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), signer.Address())
+
+	signer, err = paymasterclient.NewHDWalletSigner(mnemonic, "", "m/44'/60'/0'/0/1")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"), signer.Address())
+
+	_, err = paymasterclient.NewHDWalletSigner(mnemonic, "", "m/not/a/path")
+	assert.Error(t, err)
+}
+
+// TestHDWalletSignerInvalidMnemonic checks that mistyped mnemonics are rejected rather than deriving another account.
+func TestHDWalletSignerInvalidMnemonic(t *testing.T) {
+	for _, mnemonic := range []string{
+		// Valid words, but the last one should be "about".
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abuot",
+		"abandon abandon abandon abandon abandon about",
+	} {
+		_, err := paymasterclient.NewHDWalletSigner(mnemonic, "", paymasterclient.DefaultDerivationPath)
+		require.ErrorIs(t, err, paymasterclient.ErrInvalidMnemonic, mnemonic)
+		assert.NotContains(t, err.Error(), "abuot")
+	}
+}
+
+// TestKeystoreSigner decrypts a keystore file and signs with it.
+func TestKeystoreSigner(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(privateKey, "secret")
+	require.NoError(t, err)
+
+	signer, err := paymasterclient.NewKeystoreFileSigner(account.URL.Path, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, address, signer.Address())
+
+	keyJSON, err := os.ReadFile(account.URL.Path)
+	require.NoError(t, err)
+	_, err = paymasterclient.NewKeystoreSigner(keyJSON, "wrong")
+	assert.ErrorIs(t, err, keystore.ErrDecrypt)
+
+	hexSigner, err := paymasterclient.NewHexKeySigner(hexutil.Encode(crypto.FromECDSA(privateKey)))
+	require.NoError(t, err)
+	assert.Equal(t, address, hexSigner.Address())
+}
+
+// clefStub implements account_signTransaction like clef, decoding the same arguments and signing with a local key.
+type clefStub struct {
+	key    *ecdsa.PrivateKey
+	tamper atomic.Bool // tamper makes the stub sign another transaction than the one requested.
+}
+
+func (s *clefStub) SignTransaction(ctx context.Context, args apitypes.SendTxArgs, methodSelector *string) (map[string]interface{}, error) {
+	if s.tamper.Load() {
+		args.Value = hexutil.Big(*big.NewInt(1e18))
+	}
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), s.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signed}, nil
+}
+
+// TestExternalSigner signs through a clef stub and sends the result through the gasless flow.
+func TestExternalSigner(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	stub := &clefStub{key: privateKey}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("account", stub))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	signer, err := paymasterclient.NewExternalSigner(context.Background(), httpServer.URL, address)
+	require.NoError(t, err)
+	defer signer.Close()
+
+	megafuel, client := startMegaFuel(t, nil)
+	sender := paymasterclient.NewGaslessSender(client, signer, nil)
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+	result, err := sender.Send(context.Background(), paymasterclient.GaslessRequest{To: &toAddress, Value: big.NewInt(1)})
+	require.NoError(t, err)
+	require.Len(t, megafuel.Transactions(), 1)
+	assert.Equal(t, result.TxHash, megafuel.Transactions()[0].Hash())
+
+	// A signature for another transaction is refused.
+	stub.tamper.Store(true)
+	tx := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(0), Gas: 21000, To: &toAddress, Value: big.NewInt(0)})
+	_, err = signer.SignTx(context.Background(), tx, big.NewInt(97))
+	assert.ErrorIs(t, err, paymasterclient.ErrSignerMismatch)
+
+	// So is a signature by another account.
+	other, err := paymasterclient.NewExternalSigner(context.Background(), httpServer.URL, toAddress)
+	require.NoError(t, err)
+	defer other.Close()
+	stub.tamper.Store(false)
+	_, err = other.SignTx(context.Background(), tx, big.NewInt(97))
+	assert.ErrorIs(t, err, paymasterclient.ErrSignerMismatch)
+}
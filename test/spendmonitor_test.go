@@ -0,0 +1,253 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/spendmonitor"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+	mftypes "github.com/node-real/megafuel-go-sdk/pkg/types"
+)
+
+// TestSpendMonitor polls spend data and sends deduplicated alerts and recoveries to every notifier.
+func TestSpendMonitor(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	alice, bob := common.HexToAddress("0xa1"), common.HexToAddress("0xb0b")
+	setSpend := func(cost int64, aliceTxs, bobTxs uint64) {
+		server.SetPolicySpendData(policyUUID, sponsorclient.PolicySpendData{Cost: (*mftypes.Big)(big.NewInt(cost))})
+		for user, txs := range map[common.Address]uint64{alice: aliceTxs, bob: bobTxs} {
+			server.SetUserSpendData(policyUUID, sponsorclient.UserSpendData{
+				UserAddress:   user,
+				GasCost:       (*mftypes.Big)(big.NewInt(int64(txs) * 100)),
+				GasCostCurDay: (*mftypes.Big)(big.NewInt(int64(txs) * 100)),
+				TxCountCurDay: txs,
+			})
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		webhooks []spendmonitor.Alert
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		var alert spendmonitor.Alert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		mu.Lock()
+		webhooks = append(webhooks, alert)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	var lines bytes.Buffer
+	alerts := make(chan spendmonitor.Alert, 10)
+	monitor := spendmonitor.NewMonitor(sponsor, &spendmonitor.Options{
+		Watches: []spendmonitor.Watch{{PolicyUUID: policyUUID, Users: []common.Address{alice, bob}}},
+		Thresholds: []spendmonitor.Threshold{
+			{Metric: spendmonitor.PolicyCost, Limit: big.NewInt(1000)},
+			{Metric: spendmonitor.UserTxCountCurDay, PolicyUUID: policyUUID, Limit: big.NewInt(5)},
+			{Metric: spendmonitor.UserGasCostCurDay, User: bob, Limit: big.NewInt(100)},
+		},
+		Notifiers: []spendmonitor.Notifier{
+			spendmonitor.NewChannelNotifier(alerts),
+			spendmonitor.NewWriterNotifier(&lines),
+			&spendmonitor.WebhookNotifier{URL: webhook.URL, Header: http.Header{"X-Token": {"secret"}}},
+		},
+		Interval:    -1,
+		HistorySize: 3,
+	})
+	defer monitor.Close()
+
+	// Nothing is reported before there is spend data.
+	require.NoError(t, monitor.Poll(ctx))
+	assert.Empty(t, alerts)
+
+	setSpend(500, 2, 0)
+	require.NoError(t, monitor.Poll(ctx))
+	assert.Empty(t, alerts)
+
+	setSpend(1500, 6, 0)
+	require.NoError(t, monitor.Poll(ctx))
+	require.Len(t, alerts, 2)
+	fired := <-alerts
+	assert.Equal(t, spendmonitor.AlertFired, fired.Kind)
+	assert.Equal(t, spendmonitor.PolicyCost, fired.Threshold.Metric)
+	assert.Equal(t, big.NewInt(1500), fired.Value)
+	fired = <-alerts
+	assert.Equal(t, spendmonitor.UserTxCountCurDay, fired.Threshold.Metric)
+	assert.Equal(t, alice, fired.User)
+	assert.Len(t, monitor.Firing(), 2)
+
+	// Alerts are not repeated while the values stay above the thresholds.
+	setSpend(1600, 7, 1)
+	require.NoError(t, monitor.Poll(ctx))
+	require.Len(t, alerts, 1)
+	fired = <-alerts
+	assert.Equal(t, spendmonitor.UserGasCostCurDay, fired.Threshold.Metric)
+	assert.Equal(t, bob, fired.User)
+
+	// The daily counters reset.
+	server.SetUserSpendData(policyUUID, sponsorclient.UserSpendData{UserAddress: alice, GasCost: (*mftypes.Big)(big.NewInt(700)), GasCostCurDay: (*mftypes.Big)(new(big.Int))})
+	require.NoError(t, monitor.Poll(ctx))
+	require.Len(t, alerts, 1)
+	recovered := <-alerts
+	assert.Equal(t, spendmonitor.AlertRecovered, recovered.Kind)
+	assert.Equal(t, alice, recovered.User)
+	assert.Equal(t, big.NewInt(0), recovered.Value)
+
+	mu.Lock()
+	assert.Len(t, webhooks, 4)
+	assert.Equal(t, spendmonitor.AlertRecovered, webhooks[3].Kind)
+	mu.Unlock()
+	assert.Contains(t, lines.String(), "UserTxCountCurDay recovered: user "+alice.Hex())
+
+	history := monitor.History(policyUUID)
+	require.Len(t, history, 3)
+	assert.Equal(t, big.NewInt(1600), history[2].Policy.Cost.Raw())
+	assert.Equal(t, uint64(0), history[2].Users[alice].TxCountCurDay)
+	assert.Equal(t, big.NewInt(1500), history[0].Policy.Cost.Raw())
+
+	// Poll errors keep the threshold states.
+	server.FailNext("pm_getPolicySpendData", &megafueltest.Error{Code: -32000, Message: "internal error"})
+	assert.Error(t, monitor.Poll(ctx))
+	assert.Empty(t, alerts)
+	assert.Len(t, monitor.Firing(), 2)
+}
+
+// TestSpendMonitorBackground polls in the background until closed.
+func TestSpendMonitorBackground(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	server.SetPolicySpendData(policyUUID, sponsorclient.PolicySpendData{Cost: (*mftypes.Big)(big.NewInt(10))})
+
+	alerts := make(chan spendmonitor.Alert, 1)
+	monitor := spendmonitor.NewMonitor(sponsor, &spendmonitor.Options{
+		Watches:    []spendmonitor.Watch{{PolicyUUID: policyUUID}},
+		Thresholds: []spendmonitor.Threshold{{Metric: spendmonitor.PolicyCost, Limit: big.NewInt(10)}},
+		Notifiers:  []spendmonitor.Notifier{spendmonitor.NewChannelNotifier(alerts)},
+		Interval:   10 * time.Millisecond,
+	})
+	select {
+	case alert := <-alerts:
+		assert.Equal(t, spendmonitor.AlertFired, alert.Kind)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert")
+	}
+	monitor.Close()
+	monitor.Close()
+	assert.NotEmpty(t, monitor.History(policyUUID))
+}
+
+// TestSpendMonitorDailyCost fires on the policy cost over the daily window, computed from the history.
+func TestSpendMonitorDailyCost(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	setCost := func(cost int64) {
+		server.SetPolicySpendData(policyUUID, sponsorclient.PolicySpendData{Cost: (*mftypes.Big)(big.NewInt(cost))})
+	}
+
+	alerts := make(chan spendmonitor.Alert, 10)
+	monitor := spendmonitor.NewMonitor(sponsor, &spendmonitor.Options{
+		Watches:     []spendmonitor.Watch{{PolicyUUID: policyUUID}},
+		Thresholds:  []spendmonitor.Threshold{{Metric: spendmonitor.PolicyDailyCost, Limit: big.NewInt(400)}},
+		Notifiers:   []spendmonitor.Notifier{spendmonitor.NewChannelNotifier(alerts)},
+		Interval:    -1,
+		DailyWindow: 200 * time.Millisecond,
+	})
+	defer monitor.Close()
+
+	// The total cost is above the limit, but nothing was spent within the window yet.
+	setCost(1000)
+	require.NoError(t, monitor.Poll(ctx))
+	assert.Empty(t, alerts)
+
+	setCost(1500)
+	require.NoError(t, monitor.Poll(ctx))
+	require.Len(t, alerts, 1)
+	fired := <-alerts
+	assert.Equal(t, spendmonitor.AlertFired, fired.Kind)
+	assert.Equal(t, big.NewInt(500), fired.Value)
+
+	// Once the window has passed, only the cost since the last sample out of the window counts.
+	time.Sleep(250 * time.Millisecond)
+	setCost(1600)
+	require.NoError(t, monitor.Poll(ctx))
+	require.Len(t, alerts, 1)
+	recovered := <-alerts
+	assert.Equal(t, spendmonitor.AlertRecovered, recovered.Kind)
+	assert.Equal(t, big.NewInt(100), recovered.Value)
+}
+
+// TestSpendMonitorRetriesUndelivered sends an alert again on the next poll when no notifier delivered it.
+func TestSpendMonitorRetriesUndelivered(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+	server.SetPolicySpendData(policyUUID, sponsorclient.PolicySpendData{Cost: (*mftypes.Big)(big.NewInt(10))})
+
+	var (
+		mu       sync.Mutex
+		failing  = true
+		webhooks []spendmonitor.Alert
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var alert spendmonitor.Alert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		webhooks = append(webhooks, alert)
+	}))
+	defer webhook.Close()
+
+	monitor := spendmonitor.NewMonitor(sponsor, &spendmonitor.Options{
+		Watches:    []spendmonitor.Watch{{PolicyUUID: policyUUID}},
+		Thresholds: []spendmonitor.Threshold{{Metric: spendmonitor.PolicyCost, Limit: big.NewInt(10)}},
+		Notifiers:  []spendmonitor.Notifier{&spendmonitor.WebhookNotifier{URL: webhook.URL}},
+		Interval:   -1,
+	})
+	defer monitor.Close()
+
+	require.Error(t, monitor.Poll(ctx))
+	assert.Empty(t, monitor.Firing())
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	require.NoError(t, monitor.Poll(ctx))
+	assert.Len(t, monitor.Firing(), 1)
+	require.NoError(t, monitor.Poll(ctx))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, spendmonitor.AlertFired, webhooks[0].Kind)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/node-real/megafuel-go-sdk/pkg/networks"
 	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
 )
 
@@ -44,6 +45,22 @@ func TestSponsorAPI(t *testing.T) {
 	testEmptyWhitelist(t, sponsorClient, policyUUID)
 }
 
+// TestSponsorForNetwork updates a whitelist through a client created from the testnet preset.
+func TestSponsorForNetwork(t *testing.T) {
+	key := os.Getenv("OPEN_PLATFORM_PRIVATE_KEY")
+	if key == "" {
+		log.Fatal("Environment variable OPEN_PLATFORM_PRIVATE_KEY is not set")
+	}
+	sponsorClient, err := sponsorclient.NewForNetwork(context.Background(), networks.BSCTestnet, key)
+	require.NoError(t, err, "Setup should not fail")
+
+	policyUUID, err := uuid.FromString(POLICY_UUID)
+	require.NoError(t, err, "Failed to parse UUID")
+
+	testAddToWhitelist(t, sponsorClient, policyUUID, RECIPIENT_ADDRESS)
+	testRemoveFromWhitelist(t, sponsorClient, policyUUID, RECIPIENT_ADDRESS)
+}
+
 // testAddToWhitelist tests the addition of an address to the ToAccountWhitelist.
 func testAddToWhitelist(t *testing.T, client sponsorclient.Client, policyUUID uuid.UUID, address string) {
 	success, err := client.AddToWhitelist(context.Background(), sponsorclient.WhiteListArgs{
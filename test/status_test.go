@@ -0,0 +1,95 @@
+package test
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestStatusCodec checks the names of statuses, their numeric JSON form and that JSON decoding accepts numbers and names.
+func TestStatusCodec(t *testing.T) {
+	assert.Equal(t, "confirmed", paymasterclient.StatusConfirmed.String())
+	assert.Equal(t, "7", paymasterclient.Status(7).String())
+
+	// Statuses are encoded as numbers like the API does, so re-serialized responses keep their shape.
+	data, err := json.Marshal(paymasterclient.StatusPending)
+	require.NoError(t, err)
+	assert.Equal(t, `1`, string(data))
+	data, err = json.Marshal(paymasterclient.TransactionResponse{Status: paymasterclient.StatusConfirmed})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"status":2`)
+
+	var tx paymasterclient.TransactionResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"status":2}`), &tx))
+	assert.Equal(t, paymasterclient.StatusConfirmed, tx.Status)
+	require.NoError(t, json.Unmarshal([]byte(`{"status":"Failed"}`), &tx))
+	assert.Equal(t, paymasterclient.StatusFailed, tx.Status)
+	require.NoError(t, json.Unmarshal([]byte(`{"status":"4"}`), &tx))
+	assert.Equal(t, paymasterclient.StatusInvalid, tx.Status)
+	assert.Error(t, json.Unmarshal([]byte(`{"status":"mined"}`), &tx))
+
+	// Statuses unknown to the SDK survive a round trip.
+	var status paymasterclient.Status
+	require.NoError(t, json.Unmarshal([]byte(`9`), &status))
+	data, err = json.Marshal(status)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &status))
+	assert.Equal(t, paymasterclient.Status(9), status)
+	assert.False(t, status.IsKnown())
+}
+
+// TestStatusText round trips the text form of statuses, used by map keys and flags.
+func TestStatusText(t *testing.T) {
+	counts := map[paymasterclient.Status]int{paymasterclient.StatusConfirmed: 2, paymasterclient.Status(9): 1}
+	data, err := json.Marshal(counts)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"confirmed":2,"9":1}`, string(data))
+	var decoded map[paymasterclient.Status]int
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, counts, decoded)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	var status paymasterclient.Status
+	flags.TextVar(&status, "status", paymasterclient.StatusNew, "status")
+	require.NoError(t, flags.Parse([]string{"-status", "Pending"}))
+	assert.Equal(t, paymasterclient.StatusPending, status)
+	assert.Equal(t, "pending", flags.Lookup("status").Value.String())
+	assert.Error(t, flags.Parse([]string{"-status", "mined"}))
+}
+
+// TestStatusTransitions checks the terminal and success helpers and the transition validator.
+func TestStatusTransitions(t *testing.T) {
+	assert.True(t, paymasterclient.StatusConfirmed.IsSuccess())
+	assert.False(t, paymasterclient.StatusFailed.IsSuccess())
+	assert.True(t, paymasterclient.StatusInvalid.IsTerminal())
+	assert.False(t, paymasterclient.StatusPending.IsTerminal())
+
+	for _, valid := range [][2]paymasterclient.Status{
+		{paymasterclient.StatusNew, paymasterclient.StatusPending},
+		{paymasterclient.StatusNew, paymasterclient.StatusConfirmed},
+		{paymasterclient.StatusPending, paymasterclient.StatusFailed},
+		{paymasterclient.StatusConfirmed, paymasterclient.StatusConfirmed},
+	} {
+		assert.NoError(t, paymasterclient.ValidateTransition(valid[0], valid[1]), "%s -> %s", valid[0], valid[1])
+	}
+	for _, invalid := range [][2]paymasterclient.Status{
+		{paymasterclient.StatusConfirmed, paymasterclient.StatusPending},
+		{paymasterclient.StatusFailed, paymasterclient.StatusConfirmed},
+		{paymasterclient.StatusPending, paymasterclient.StatusNew},
+		{paymasterclient.StatusNew, paymasterclient.Status(9)},
+	} {
+		assert.ErrorIs(t, paymasterclient.ValidateTransition(invalid[0], invalid[1]), paymasterclient.ErrInvalidTransition, "%s -> %s", invalid[0], invalid[1])
+	}
+
+	change := paymasterclient.StatusChange{From: paymasterclient.StatusConfirmed, To: paymasterclient.StatusPending}
+	assert.ErrorIs(t, change.Validate(), paymasterclient.ErrInvalidTransition)
+	change.First = true
+	assert.NoError(t, change.Validate())
+}
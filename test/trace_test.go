@@ -0,0 +1,112 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/mftrace"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+// TestTracing checks the spans of the traced clients and the propagation of their trace context to the endpoint.
+func TestTracing(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, raw := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	rawSponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	opts := &mftrace.Options{TracerProvider: provider, Propagator: propagation.TraceContext{}}
+	client := mftrace.NewPaymasterClient(raw, opts)
+	sponsor := mftrace.NewSponsorClient(rawSponsor, opts)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	chainID, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	input := signedTransfer(t, 0)
+	hash, err := client.SendRawTransaction(paymasterclient.WithPolicyUUID(ctx, policyUUID.String()), input, nil)
+	require.NoError(t, err)
+	server.ConfirmAll()
+	tx, err := client.GetGaslessTransactionByHash(ctx, hash)
+	require.NoError(t, err)
+	server.FailNext("eth_sendRawTransaction", &megafueltest.Error{Code: -32000, Message: "nonce too low"})
+	_, err = client.SendRawTransaction(ctx, input, nil)
+	require.Error(t, err)
+	_, err = client.BatchGetGaslessTransactions(ctx, []common.Hash{hash, common.HexToHash("0x01")})
+	require.NoError(t, err)
+	_, err = sponsor.GetPolicySpendData(ctx, policyUUID)
+	require.NoError(t, err)
+	parent.End()
+
+	// The trace context of each call reaches the endpoint.
+	for _, request := range server.Requests() {
+		assert.Contains(t, request.Header.Get("Traceparent"), parent.SpanContext().TraceID().String(), request.Methods)
+	}
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 7)
+	for _, span := range spans[:6] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID(), span.Name)
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind, span.Name)
+	}
+	attrs := func(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+
+	// The chain ID learned from ChainID is recorded on the later spans.
+	chainIDSpan, sent, get, failed, batch, spend := spans[0], spans[1], spans[2], spans[3], spans[4], spans[5]
+	assert.Equal(t, "eth_chainId", chainIDSpan.Name)
+	assert.Equal(t, "jsonrpc", attrs(chainIDSpan)["rpc.system"].AsString())
+	assert.Equal(t, chainID.String(), attrs(chainIDSpan)[mftrace.ChainIDKey].AsString())
+
+	assert.Equal(t, "eth_sendRawTransaction", sent.Name)
+	assert.Equal(t, codes.Unset, sent.Status.Code)
+	assert.Equal(t, chainID.String(), attrs(sent)[mftrace.ChainIDKey].AsString())
+	assert.Equal(t, policyUUID.String(), attrs(sent)[mftrace.PolicyUUIDKey].AsString())
+	assert.Equal(t, hash.Hex(), attrs(sent)[mftrace.TxHashKey].AsString())
+
+	assert.Equal(t, "eth_getGaslessTransactionByHash", get.Name)
+	assert.Equal(t, hash.Hex(), attrs(get)[mftrace.TxHashKey].AsString())
+	assert.Equal(t, tx.BundleUUID.String(), attrs(get)[mftrace.BundleUUIDKey].AsString())
+	assert.Equal(t, paymasterclient.StatusConfirmed.String(), attrs(get)[mftrace.StatusKey].AsString())
+
+	// Failures are recorded on the span, the hash of a failed send is known from its input.
+	assert.Equal(t, codes.Error, failed.Status.Code)
+	assert.Equal(t, hash.Hex(), attrs(failed)[mftrace.TxHashKey].AsString())
+	require.Len(t, failed.Events, 1)
+	assert.Equal(t, "exception", failed.Events[0].Name)
+	eventAttrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range failed.Events[0].Attributes {
+		eventAttrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "nonce_too_low", eventAttrs[mftrace.ErrorClassKey].AsString())
+	assert.NotEmpty(t, eventAttrs[mftrace.RequestIDKey].AsString())
+
+	// A batch is one span, with the failures of its items as events.
+	assert.Equal(t, int64(2), attrs(batch)[mftrace.BatchSizeKey].AsInt64())
+	assert.Equal(t, []string{hash.Hex(), common.HexToHash("0x01").Hex()}, attrs(batch)[mftrace.TxHashKey].AsStringSlice())
+	assert.Equal(t, codes.Unset, batch.Status.Code)
+	require.Len(t, batch.Events, 1)
+
+	assert.Equal(t, "pm_getPolicySpendData", spend.Name)
+	assert.Equal(t, "sponsor", attrs(spend)["rpc.service"].AsString())
+	assert.Equal(t, policyUUID.String(), attrs(spend)[mftrace.PolicyUUIDKey].AsString())
+}
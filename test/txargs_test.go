@@ -0,0 +1,158 @@
+package test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestTransactionArgsRoundTrip converts every supported transaction type to TransactionArgs and back.
+func TestTransactionArgsRoundTrip(t *testing.T) {
+	to := common.HexToAddress(RECIPIENT_ADDRESS)
+	from := common.HexToAddress("0x01")
+	chainID := big.NewInt(97)
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x02")}}}
+
+	for _, inner := range []types.TxData{
+		&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(0), Gas: 21000, To: &to, Value: big.NewInt(5)},
+		&types.LegacyTx{Nonce: 2, GasPrice: big.NewInt(3), Gas: 60000, Data: []byte{0x60, 0x80}},
+		&types.AccessListTx{ChainID: chainID, Nonce: 3, GasPrice: big.NewInt(0), Gas: 30000, To: &to, Value: big.NewInt(0), AccessList: accessList},
+		&types.DynamicFeeTx{ChainID: chainID, Nonce: 4, GasTipCap: big.NewInt(0), GasFeeCap: big.NewInt(0), Gas: 30000, To: &to, Value: big.NewInt(1), Data: []byte{0xa9, 0x05, 0x9c, 0xbb}},
+		&types.DynamicFeeTx{ChainID: chainID, Nonce: 5, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 30000, To: &to, Value: big.NewInt(0), AccessList: types.AccessList{}},
+	} {
+		tx := types.NewTx(inner)
+		args, err := paymasterclient.NewTransactionArgs(from, tx)
+		require.NoError(t, err)
+
+		// The args survive the JSON encoding sent to IsSponsorable.
+		data, err := json.Marshal(args)
+		require.NoError(t, err)
+		var decoded paymasterclient.TransactionArgs
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		rebuilt, err := decoded.ToTransaction()
+		require.NoError(t, err)
+		assert.Equal(t, tx.Type(), rebuilt.Type())
+		assert.Equal(t, tx.Hash(), rebuilt.Hash(), "type %d nonce %d", tx.Type(), tx.Nonce())
+	}
+}
+
+// TestTransactionArgsSignedLegacy checks that the chain ID of a signed EIP-155 transaction is kept.
+func TestTransactionArgsSignedLegacy(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	to := common.HexToAddress(RECIPIENT_ADDRESS)
+	signer := types.LatestSignerForChainID(big.NewInt(97))
+	tx, err := types.SignNewTx(privateKey, signer, &types.LegacyTx{GasPrice: big.NewInt(0), Gas: 21000, To: &to, Value: big.NewInt(0)})
+	require.NoError(t, err)
+
+	args, err := paymasterclient.NewTransactionArgs(crypto.PubkeyToAddress(privateKey.PublicKey), tx)
+	require.NoError(t, err)
+	require.NotNil(t, args.ChainID)
+	assert.Equal(t, int64(97), args.ChainID.ToInt().Int64())
+
+	// Signing the rebuilt transaction gives back the original.
+	rebuilt, err := args.ToTransaction()
+	require.NoError(t, err)
+	resigned, err := types.SignTx(rebuilt, signer, privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, tx.Hash(), resigned.Hash())
+}
+
+// TestTransactionArgsUnsupported checks the errors for transactions that cannot be converted.
+func TestTransactionArgsUnsupported(t *testing.T) {
+	blobTx := types.NewTx(&types.BlobTx{
+		ChainID:    uint256.NewInt(97),
+		GasTipCap:  uint256.NewInt(0),
+		GasFeeCap:  uint256.NewInt(0),
+		Gas:        21000,
+		BlobFeeCap: uint256.NewInt(1),
+		BlobHashes: []common.Hash{{0x01}},
+		Value:      uint256.NewInt(0),
+	})
+	_, err := paymasterclient.NewTransactionArgs(common.Address{}, blobTx)
+	assert.ErrorIs(t, err, paymasterclient.ErrUnsupportedTxType)
+
+	nonce, gas := hexutil.Uint64(0), hexutil.Uint64(21000)
+	_, err = paymasterclient.TransactionArgs{Nonce: &nonce, Gas: &gas}.ToTransaction()
+	assert.ErrorIs(t, err, paymasterclient.ErrIncompleteArgs)
+	_, err = paymasterclient.TransactionArgs{Nonce: &nonce, Gas: &gas, MaxFeePerGas: (*hexutil.Big)(big.NewInt(1))}.ToTransaction()
+	assert.ErrorIs(t, err, paymasterclient.ErrIncompleteArgs)
+
+	to := common.HexToAddress(RECIPIENT_ADDRESS)
+	args := paymasterclient.TransactionArgs{
+		Nonce:             &nonce,
+		Gas:               &gas,
+		AuthorizationList: []types.SetCodeAuthorization{{ChainID: *uint256.NewInt(97)}},
+	}
+	_, err = args.ToTransaction()
+	assert.ErrorIs(t, err, paymasterclient.ErrIncompleteArgs)
+	args.To = &to
+	args.ChainID = (*hexutil.Big)(big.NewInt(97))
+	args.MaxFeePerGas = (*hexutil.Big)(big.NewInt(-1))
+	args.MaxPriorityFeePerGas = (*hexutil.Big)(big.NewInt(0))
+	_, err = args.ToTransaction()
+	assert.ErrorIs(t, err, paymasterclient.ErrInvalidArgs)
+	data, err := json.Marshal(args)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"authorizationList":[{"chainId":"0x61"`)
+}
+
+// TestTransactionArgsSetCode converts a signed EIP-7702 set code transaction to TransactionArgs and back.
+func TestTransactionArgsSetCode(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	delegate := common.HexToAddress(RECIPIENT_ADDRESS)
+	auth, err := types.SignSetCode(privateKey, types.SetCodeAuthorization{
+		ChainID: *uint256.NewInt(97),
+		Address: delegate,
+		Nonce:   1,
+	})
+	require.NoError(t, err)
+
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	signer := types.LatestSignerForChainID(big.NewInt(97))
+	tx, err := types.SignNewTx(privateKey, signer, &types.SetCodeTx{
+		ChainID:    uint256.NewInt(97),
+		Nonce:      0,
+		GasTipCap:  uint256.NewInt(0),
+		GasFeeCap:  uint256.NewInt(0),
+		Gas:        60000,
+		To:         from,
+		Value:      uint256.NewInt(0),
+		Data:       []byte{0x01},
+		AccessList: types.AccessList{{Address: delegate, StorageKeys: []common.Hash{{0x02}}}},
+		AuthList:   []types.SetCodeAuthorization{auth},
+	})
+	require.NoError(t, err)
+
+	args, err := paymasterclient.NewTransactionArgs(from, tx)
+	require.NoError(t, err)
+	require.Len(t, args.AuthorizationList, 1)
+	data, err := json.Marshal(args)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"authorizationList":[{"chainId":"0x61","address":"`)
+	var decoded paymasterclient.TransactionArgs
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	// The authorization keeps its signature, so the rebuilt transaction signs to the original.
+	rebuilt, err := decoded.ToTransaction()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(types.SetCodeTxType), rebuilt.Type())
+	authority, err := rebuilt.SetCodeAuthorizations()[0].Authority()
+	require.NoError(t, err)
+	assert.Equal(t, from, authority)
+	resigned, err := types.SignTx(rebuilt, signer, privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, tx.Hash(), resigned.Hash())
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/paymasterclient"
+)
+
+// TestWaitForGaslessTransaction waits for a scripted transaction to be confirmed and checks every reported transition.
+func TestWaitForGaslessTransaction(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := paymasterclient.NewGaslessSender(client, paymasterclient.NewPrivateKeySigner(privateKey), nil)
+
+	toAddress := common.HexToAddress(RECIPIENT_ADDRESS)
+	result, err := sender.Send(context.Background(), paymasterclient.GaslessRequest{To: &toAddress})
+	require.NoError(t, err)
+
+	server.ScriptStatuses(result.TxHash,
+		paymasterclient.StatusNew,
+		paymasterclient.StatusPending,
+		paymasterclient.StatusPending,
+		paymasterclient.StatusConfirmed,
+	)
+
+	var changes []paymasterclient.StatusChange
+	final, err := paymasterclient.WaitForGaslessTransaction(context.Background(), client, result.TxHash, &paymasterclient.WaitOptions{
+		PollInterval: time.Millisecond,
+		OnStatusChange: func(change paymasterclient.StatusChange) {
+			changes = append(changes, change)
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, paymasterclient.StatusConfirmed, final.Transaction.Status)
+	require.NotNil(t, final.Bundle)
+	assert.Equal(t, final.Transaction.BundleUUID, final.Bundle.BundleUUID)
+	assert.Equal(t, paymasterclient.StatusConfirmed, final.Bundle.Status)
+
+	require.Len(t, changes, 3)
+	assert.True(t, changes[0].First)
+	assert.Equal(t, paymasterclient.StatusNew, changes[0].To)
+	assert.Equal(t, paymasterclient.StatusNew, changes[1].From)
+	assert.Equal(t, paymasterclient.StatusPending, changes[1].To)
+	assert.Equal(t, paymasterclient.StatusPending, changes[2].From)
+	assert.Equal(t, paymasterclient.StatusConfirmed, changes[2].To)
+}
+
+// TestWaitForGaslessTransactionCanceled checks that waiting honours context cancellation.
+func TestWaitForGaslessTransactionCanceled(t *testing.T) {
+	_, client := startMegaFuel(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := paymasterclient.WaitForGaslessTransaction(ctx, client, common.HexToHash("0x01"), &paymasterclient.WaitOptions{
+		PollInterval: 5 * time.Millisecond,
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestWaitForGaslessTransactionPermanentError checks that errors other than not found and retryable ones end the wait.
+func TestWaitForGaslessTransactionPermanentError(t *testing.T) {
+	server, client := startMegaFuel(t, nil)
+
+	// A transaction not indexed yet is waited for.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := paymasterclient.WaitForGaslessTransaction(ctx, client, common.HexToHash("0x01"), &paymasterclient.WaitOptions{
+		PollInterval: 5 * time.Millisecond,
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "not found")
+
+	// Even without a deadline, an unauthorized lookup is not polled again.
+	server.FailNext("eth_getGaslessTransactionByHash", &megafueltest.Error{Code: -32000, Message: "unauthorized"})
+	_, err = paymasterclient.WaitForGaslessTransaction(context.Background(), client, common.HexToHash("0x01"), &paymasterclient.WaitOptions{
+		PollInterval: 5 * time.Millisecond,
+	})
+	require.ErrorIs(t, err, mferrors.ErrUnauthorized)
+
+	server.FailNext("eth_getGaslessTransactionByHash", &megafueltest.Error{Code: mferrors.CodeInvalidParams, Message: "invalid argument"})
+	_, err = paymasterclient.WaitForGaslessTransaction(context.Background(), client, common.HexToHash("0x01"), nil)
+	require.ErrorIs(t, err, mferrors.ErrInvalidParams)
+}
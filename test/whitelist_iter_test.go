@@ -0,0 +1,93 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+// TestWhitelistValues ranges over a whitelist and stops early.
+func TestWhitelistValues(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	values := whitelistAddresses(25)
+	_, err = sponsor.AddToWhitelist(ctx, sponsorclient.WhiteListArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.FromAccountWhitelist, Values: values})
+	require.NoError(t, err)
+
+	args := sponsorclient.GetWhitelistArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.FromAccountWhitelist, Limit: 10}
+	var listed []string
+	for value, err := range sponsorclient.WhitelistValues(ctx, sponsor, args) {
+		require.NoError(t, err)
+		listed = append(listed, value)
+	}
+	assert.Equal(t, values, listed)
+
+	listed = nil
+	for value := range sponsorclient.WhitelistValues(ctx, sponsor, args) {
+		if len(listed) == 12 {
+			break
+		}
+		listed = append(listed, value)
+	}
+	assert.Equal(t, values[:12], listed)
+
+	server.FailNext("pm_getWhitelist", &megafueltest.Error{Code: -32000, Message: "internal error"})
+	var errs int
+	for _, err := range sponsorclient.WhitelistValues(ctx, sponsor, args) {
+		if err != nil {
+			errs++
+		}
+	}
+	assert.Equal(t, 1, errs)
+}
+
+// TestWhitelistValuesAtMaxOffset lists a whitelist of exactly MaxOffset values, which is not truncated.
+func TestWhitelistValuesAtMaxOffset(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	values := whitelistAddresses(sponsorclient.MaxOffset)
+	_, err = sponsorclient.AddToWhitelistChunked(ctx, sponsor, sponsorclient.WhiteListArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.FromAccountWhitelist,
+		Values:        values,
+	}, &sponsorclient.ChunkOptions{ChunkSize: 100, Concurrency: 1})
+	require.NoError(t, err)
+
+	args := sponsorclient.GetWhitelistArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.FromAccountWhitelist, Limit: 100}
+	var listed []string
+	for value, err := range sponsorclient.WhitelistValues(ctx, sponsor, args) {
+		require.NoError(t, err)
+		listed = append(listed, value)
+	}
+	assert.Equal(t, values, listed)
+
+	// One more value and the whitelist is truncated.
+	_, err = sponsor.AddToWhitelist(ctx, sponsorclient.WhiteListArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.FromAccountWhitelist, Values: whitelistAddresses(sponsorclient.MaxOffset + 1)[sponsorclient.MaxOffset:]})
+	require.NoError(t, err)
+	listed = nil
+	var errs []error
+	for value, err := range sponsorclient.WhitelistValues(ctx, sponsor, args) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		listed = append(listed, value)
+	}
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], sponsorclient.ErrWhitelistTruncated)
+	assert.Equal(t, values, listed)
+}
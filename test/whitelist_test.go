@@ -0,0 +1,212 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/mferrors"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+)
+
+// whitelistBatchSize is the maximum number of values per whitelist update of the fake server of TestWhitelistChunked.
+const whitelistBatchSize = 100
+
+func whitelistAddresses(n int) []string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+	}
+	return values
+}
+
+// TestWhitelistChunked adds and removes more values than a single call accepts, with a failing chunk.
+func TestWhitelistChunked(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID, WhitelistBatchSize: whitelistBatchSize})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	values := whitelistAddresses(2*whitelistBatchSize + 50)
+	args := sponsorclient.WhiteListArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.FromAccountWhitelist, Values: values}
+
+	_, err = sponsor.AddToWhitelist(ctx, args)
+	assert.ErrorIs(t, err, mferrors.ErrInvalidParams)
+
+	// The server limit is not known, so the chunk size has no default.
+	requests := len(server.Requests())
+	_, err = sponsorclient.AddToWhitelistChunked(ctx, sponsor, args, &sponsorclient.ChunkOptions{Concurrency: 1})
+	require.ErrorIs(t, err, sponsorclient.ErrNoChunkSize)
+	assert.Len(t, server.Requests(), requests)
+
+	report, err := sponsorclient.AddToWhitelistChunked(ctx, sponsor, args, &sponsorclient.ChunkOptions{ChunkSize: whitelistBatchSize})
+	require.NoError(t, err)
+	require.Len(t, report.Chunks, 3)
+	assert.Len(t, report.Chunks[2].Values, 50)
+	assert.Equal(t, values, report.Succeeded())
+	assert.Empty(t, report.Failed())
+	assert.ElementsMatch(t, values, server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist))
+
+	server.FailNext("pm_rmFromWhitelist", &megafueltest.Error{Code: -32000, Message: "internal error"})
+	report, err = sponsorclient.RmFromWhitelistChunked(ctx, sponsor, args, &sponsorclient.ChunkOptions{ChunkSize: 60, Concurrency: 1})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "1 of 5 whitelist chunks failed")
+	require.Len(t, report.Chunks, 5)
+	assert.Error(t, report.Chunks[0].Err)
+	assert.Equal(t, values[:60], report.Failed())
+	assert.Equal(t, values[60:], report.Succeeded())
+	assert.ElementsMatch(t, values[:60], server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist))
+
+	report, err = sponsorclient.RmFromWhitelistChunked(ctx, sponsor, sponsorclient.WhiteListArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.FromAccountWhitelist,
+		Values:        report.Failed(),
+	}, &sponsorclient.ChunkOptions{ChunkSize: whitelistBatchSize})
+	require.NoError(t, err)
+	assert.Len(t, report.Chunks, 1)
+	assert.Empty(t, server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist))
+}
+
+// TestWhitelistIterator walks whitelists page by page, up to MaxOffset.
+func TestWhitelistIterator(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	values := whitelistAddresses(250)
+	_, err = sponsorclient.AddToWhitelistChunked(ctx, sponsor, sponsorclient.WhiteListArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.ToAccountWhitelist,
+		Values:        values,
+	}, &sponsorclient.ChunkOptions{ChunkSize: 100, Concurrency: 1})
+	require.NoError(t, err)
+
+	args := sponsorclient.GetWhitelistArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.ToAccountWhitelist, Limit: 100}
+	page, err := sponsor.GetWhitelistPage(ctx, args)
+	require.NoError(t, err)
+	assert.Equal(t, values[:100], page.Values)
+	assert.Equal(t, -1, page.Total)
+
+	var pages int
+	it := sponsorclient.NewWhitelistIterator(sponsor, args)
+	for it.Next(ctx) {
+		pages++
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, 3, pages)
+	assert.Len(t, it.Page(), 50)
+
+	listed, err := sponsorclient.ListWhitelist(ctx, sponsor, sponsorclient.GetWhitelistArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.ToAccountWhitelist, Offset: 10})
+	require.NoError(t, err)
+	assert.Equal(t, values[10:], listed)
+
+	listed, err = sponsorclient.ListWhitelist(ctx, sponsor, sponsorclient.GetWhitelistArgs{PolicyUUID: policyUUID, WhitelistType: sponsorclient.FromAccountWhitelist})
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+
+	// Values past MaxOffset cannot be listed.
+	values = whitelistAddresses(sponsorclient.MaxOffset + 50)
+	_, err = sponsorclient.AddToWhitelistChunked(ctx, sponsor, sponsorclient.WhiteListArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.ToAccountWhitelist,
+		Values:        values,
+	}, &sponsorclient.ChunkOptions{ChunkSize: 100, Concurrency: 1})
+	require.NoError(t, err)
+	listed, err = sponsorclient.ListWhitelist(ctx, sponsor, args)
+	assert.ErrorIs(t, err, sponsorclient.ErrWhitelistTruncated)
+	assert.Equal(t, values[:sponsorclient.MaxOffset], listed)
+}
+
+// TestWhitelistPageDecoding decodes the array and object forms of a whitelist page.
+func TestWhitelistPageDecoding(t *testing.T) {
+	tests := []struct {
+		json   string
+		values []string
+		total  int
+	}{
+		{`["0x01","0x02"]`, []string{"0x01", "0x02"}, -1},
+		{`[]`, []string{}, -1},
+		{`{"values":["0x01"],"total":7}`, []string{"0x01"}, 7},
+		{`{"list":["0x01"],"totalSize":3}`, []string{"0x01"}, 3},
+		{`{}`, []string{}, -1},
+	}
+	for _, tt := range tests {
+		var page sponsorclient.WhitelistPage
+		require.NoError(t, json.Unmarshal([]byte(tt.json), &page), tt.json)
+		assert.Equal(t, tt.values, page.Values, tt.json)
+		assert.Equal(t, tt.total, page.Total, tt.json)
+	}
+
+	var page sponsorclient.WhitelistPage
+	assert.Error(t, json.Unmarshal([]byte(`"0x01"`), &page))
+}
+
+// TestWhitelistValidation normalizes whitelist values and rejects invalid ones before sending anything.
+func TestWhitelistValidation(t *testing.T) {
+	tests := []struct {
+		whitelistType sponsorclient.WhitelistType
+		value         string
+		want          string // want is empty if the value is invalid.
+	}{
+		{sponsorclient.FromAccountWhitelist, "0xde08b1fd79b7016f8dd3df11f7fa0fbfdf07c941", RECIPIENT_ADDRESS},
+		{sponsorclient.ToAccountWhitelist, " 0XDE08B1FD79B7016F8DD3DF11F7FA0FBFDF07C941 ", RECIPIENT_ADDRESS},
+		{sponsorclient.BEP20ReceiverWhiteList, RECIPIENT_ADDRESS, RECIPIENT_ADDRESS},
+		{sponsorclient.FromAccountWhitelist, "0xDE08B1Fd79b7016F8DD3Df11f7fa0FbfdF07c942", ""},
+		{sponsorclient.FromAccountWhitelist, "de08b1fd79b7016f8dd3df11f7fa0fbfdf07c941", ""},
+		{sponsorclient.FromAccountWhitelist, "0xde08b1fd79b7016f8dd3df11f7fa0fbfdf07c9", ""},
+		{sponsorclient.ContractMethodSigWhitelist, "0xA9059CBB", "0xa9059cbb"},
+		{sponsorclient.ContractMethodSigWhitelist, "0xa9059cb", ""},
+		{sponsorclient.ContractMethodSigWhitelist, "a9059cbb", ""},
+		{sponsorclient.ContractMethodSigWhitelist, "transfer(address,uint256)", ""},
+		{sponsorclient.WhitelistType("FutureWhitelist"), " anything ", "anything"},
+	}
+	for _, tt := range tests {
+		got, err := tt.whitelistType.NormalizeValue(tt.value)
+		if tt.want == "" {
+			assert.Error(t, err, "%s %q", tt.whitelistType, tt.value)
+			continue
+		}
+		require.NoError(t, err, "%s %q", tt.whitelistType, tt.value)
+		assert.Equal(t, tt.want, got)
+	}
+
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	args := sponsorclient.WhiteListArgs{
+		PolicyUUID:    policyUUID,
+		WhitelistType: sponsorclient.FromAccountWhitelist,
+		Values:        []string{RECIPIENT_ADDRESS, "0x01", strings.ToLower(RECIPIENT_ADDRESS), "bob"},
+	}
+	_, err = sponsor.AddToWhitelist(ctx, args)
+	assert.ErrorIs(t, err, mferrors.ErrInvalidParams)
+	var verr *sponsorclient.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []int{1, 3}, verr.Indices())
+	assert.Equal(t, "bob", verr.Invalid[1].Value)
+	assert.ErrorContains(t, err, "indices 1, 3")
+
+	report, err := sponsorclient.AddToWhitelistChunked(ctx, sponsor, args, &sponsorclient.ChunkOptions{ChunkSize: 1})
+	require.ErrorAs(t, err, &verr)
+	assert.Empty(t, report.Chunks)
+	assert.Empty(t, server.Requests())
+
+	args.Values = []string{strings.ToLower(RECIPIENT_ADDRESS)}
+	_, err = sponsor.AddToWhitelist(ctx, args)
+	require.NoError(t, err)
+	assert.Equal(t, []string{RECIPIENT_ADDRESS}, server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist))
+}
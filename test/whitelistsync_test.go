@@ -0,0 +1,127 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/node-real/megafuel-go-sdk/pkg/megafueltest"
+	"github.com/node-real/megafuel-go-sdk/pkg/sponsorclient"
+	"github.com/node-real/megafuel-go-sdk/pkg/whitelistsync"
+)
+
+// TestWhitelistSyncParse parses YAML and JSON desired states.
+func TestWhitelistSyncParse(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	yamlConfig, err := whitelistsync.Parse([]byte(fmt.Sprintf(`
+%s:
+  FromAccountWhitelist:
+    - %s
+  ToAccountWhitelist: []
+`, policyUUID, RECIPIENT_ADDRESS)))
+	require.NoError(t, err)
+	jsonConfig, err := whitelistsync.Parse([]byte(fmt.Sprintf(`{"%s": {"FromAccountWhitelist": ["%s"], "ToAccountWhitelist": []}}`, policyUUID, RECIPIENT_ADDRESS)))
+	require.NoError(t, err)
+	assert.Equal(t, whitelistsync.Config{policyUUID: {
+		sponsorclient.FromAccountWhitelist: {RECIPIENT_ADDRESS},
+		sponsorclient.ToAccountWhitelist:   {},
+	}}, yamlConfig)
+	assert.Equal(t, yamlConfig, jsonConfig)
+
+	_, err = whitelistsync.Parse([]byte(`not-a-uuid: {}`))
+	assert.ErrorContains(t, err, "not-a-uuid")
+	_, err = whitelistsync.Parse([]byte(`[1, 2]`))
+	assert.Error(t, err)
+}
+
+// TestWhitelistSyncReconcile plans and applies a desired state, with dry runs and confirmation.
+func TestWhitelistSyncReconcile(t *testing.T) {
+	policyUUID := uuid.Must(uuid.NewV4())
+	server, _ := startMegaFuel(t, &megafueltest.Options{DefaultPolicyUUID: policyUUID})
+	sponsor, err := sponsorclient.New(context.Background(), server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	kept, removed, added := common.HexToAddress("0x01").Hex(), common.HexToAddress("0x02").Hex(), common.HexToAddress("0x03").Hex()
+	for whitelistType, values := range map[sponsorclient.WhitelistType][]string{
+		sponsorclient.FromAccountWhitelist:       {kept, removed},
+		sponsorclient.ToAccountWhitelist:         {kept},
+		sponsorclient.BEP20ReceiverWhiteList:     {kept},
+		sponsorclient.ContractMethodSigWhitelist: {"0x095ea7b3"},
+	} {
+		_, err := sponsor.AddToWhitelist(ctx, sponsorclient.WhiteListArgs{PolicyUUID: policyUUID, WhitelistType: whitelistType, Values: values})
+		require.NoError(t, err)
+	}
+
+	desired := whitelistsync.Config{policyUUID: {
+		sponsorclient.FromAccountWhitelist:       {strings.ToLower(kept), added, added},
+		sponsorclient.ToAccountWhitelist:         {},
+		sponsorclient.ContractMethodSigWhitelist: {"transfer(address,uint256)", "0x095EA7B3"},
+	}}
+	plan, err := whitelistsync.NewPlan(ctx, sponsor, desired)
+	require.NoError(t, err)
+	assert.Equal(t, []whitelistsync.Change{
+		{PolicyUUID: policyUUID, WhitelistType: sponsorclient.ContractMethodSigWhitelist, Add: []string{"0xa9059cbb"}},
+		{PolicyUUID: policyUUID, WhitelistType: sponsorclient.FromAccountWhitelist, Add: []string{added}, Remove: []string{removed}},
+		{PolicyUUID: policyUUID, WhitelistType: sponsorclient.ToAccountWhitelist, Remove: []string{kept}, Empty: true},
+	}, plan.Changes)
+	assert.Contains(t, plan.String(), "  + "+added+"\n")
+	assert.Contains(t, plan.String(), "  - "+removed+"\n")
+	assert.Contains(t, plan.String(), "Plan: 2 to add, 2 to remove.")
+
+	var out bytes.Buffer
+	_, err = whitelistsync.Reconcile(ctx, sponsor, desired, &whitelistsync.Options{Out: &out, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, plan.String(), out.String())
+	_, err = whitelistsync.Reconcile(ctx, sponsor, desired, &whitelistsync.Options{
+		Confirm: func(p *whitelistsync.Plan) (bool, error) { return false, nil },
+		Chunk:   &sponsorclient.ChunkOptions{ChunkSize: 100},
+	})
+	assert.ErrorIs(t, err, whitelistsync.ErrNotConfirmed)
+	assert.ElementsMatch(t, []string{kept, removed}, server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist))
+
+	_, err = whitelistsync.Reconcile(ctx, sponsor, desired, &whitelistsync.Options{
+		Confirm: func(p *whitelistsync.Plan) (bool, error) { return true, nil },
+	})
+	require.ErrorIs(t, err, sponsorclient.ErrNoChunkSize)
+	assert.ElementsMatch(t, []string{kept, removed}, server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist))
+	_, err = whitelistsync.Reconcile(ctx, sponsor, desired, &whitelistsync.Options{
+		Confirm: func(p *whitelistsync.Plan) (bool, error) { return true, nil },
+		Chunk:   &sponsorclient.ChunkOptions{ChunkSize: 100},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{kept, added}, server.Whitelist(policyUUID, sponsorclient.FromAccountWhitelist))
+	assert.Empty(t, server.Whitelist(policyUUID, sponsorclient.ToAccountWhitelist))
+	assert.Equal(t, []string{kept}, server.Whitelist(policyUUID, sponsorclient.BEP20ReceiverWhiteList))
+	assert.ElementsMatch(t, []string{"0x095ea7b3", "0xa9059cbb"}, server.Whitelist(policyUUID, sponsorclient.ContractMethodSigWhitelist))
+
+	plan, err = whitelistsync.NewPlan(ctx, sponsor, desired)
+	require.NoError(t, err)
+	assert.True(t, plan.IsEmpty())
+	assert.Contains(t, plan.String(), "No changes")
+
+	// Invalid values fail the plan before anything is read.
+	requests := len(server.Requests())
+	_, err = whitelistsync.NewPlan(ctx, sponsor, whitelistsync.Config{policyUUID: {sponsorclient.FromAccountWhitelist: {kept, "0x1234"}}})
+	var verr *sponsorclient.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []int{1}, verr.Indices())
+	assert.Len(t, server.Requests(), requests)
+
+	// Failed changes do not stop the others.
+	server.FailNext("pm_emptyWhitelist", &megafueltest.Error{Code: -32000, Message: "internal error"})
+	err = whitelistsync.Apply(ctx, sponsor, &whitelistsync.Plan{Changes: []whitelistsync.Change{
+		{PolicyUUID: policyUUID, WhitelistType: sponsorclient.BEP20ReceiverWhiteList, Remove: []string{kept}, Empty: true},
+		{PolicyUUID: policyUUID, WhitelistType: sponsorclient.ToAccountWhitelist, Add: []string{added}},
+	}}, &sponsorclient.ChunkOptions{ChunkSize: 100})
+	assert.ErrorContains(t, err, "BEP20ReceiverWhiteList")
+	assert.Equal(t, []string{kept}, server.Whitelist(policyUUID, sponsorclient.BEP20ReceiverWhiteList))
+	assert.Equal(t, []string{added}, server.Whitelist(policyUUID, sponsorclient.ToAccountWhitelist))
+}